@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The server splits the arguments of a command at every comma and there
+// is no way to escape one. The methods of [Client] therefore encode their
+// arguments before sending them:
+//
+//   - Player IDs must be valid Steam or EOS IDs (see [PlayerID]).
+//   - Names (classes, numbers) must not contain commas.
+//   - Free text (messages and reasons) has every comma replaced by
+//     [CommaSubstitute], which looks the same in game.
+//
+// No argument may contain control characters or invalid UTF-8 and no
+// argument may be longer than [MaxArgumentLength] bytes. Arguments that
+// break these rules make the method fail with an [*ArgumentError] instead
+// of sending a corrupted command.
+//
+// [Client.ExecCommand] does not encode its parameters.
+
+// CommaSubstitute replaces commas in free text arguments. It is the
+// character U+201A SINGLE LOW-9 QUOTATION MARK.
+const CommaSubstitute = "‚"
+
+// MaxArgumentLength is the maximum length of a single argument in bytes.
+const MaxArgumentLength = 512
+
+// An ArgumentError describes an argument that cannot be sent to the server.
+type ArgumentError struct {
+	Command  MessageType
+	Index    int    // Position of the argument, starting at 0
+	Argument string // The argument as given by the caller
+	Err      error  // Why the argument was rejected
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("argument %d of command 0x%02x: %v", e.Index, e.Command, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+type argumentKind int
+
+const (
+	argumentID argumentKind = iota
+	argumentName
+	argumentText
+)
+
+type argument struct {
+	kind  argumentKind
+	value string
+}
+
+func idArgument(id PlayerID) argument {
+	return argument{argumentID, string(id)}
+}
+
+func nameArgument(value string) argument {
+	return argument{argumentName, value}
+}
+
+func textArgument(value string) argument {
+	return argument{argumentText, value}
+}
+
+// encodeArguments checks and encodes the arguments of a command.
+func encodeArguments(command MessageType, args ...argument) ([]string, error) {
+	params := make([]string, len(args))
+	for i, arg := range args {
+		param, err := encodeArgument(arg)
+		if err != nil {
+			return nil, &ArgumentError{command, i, arg.value, err}
+		}
+		params[i] = param
+	}
+	return params, nil
+}
+
+func encodeArgument(arg argument) (string, error) {
+	value := arg.value
+
+	switch arg.kind {
+	case argumentID:
+		if !PlayerID(value).Valid() {
+			return "", ErrInvalidPlayerID
+		}
+	case argumentName:
+		if strings.Contains(value, ",") {
+			return "", ErrArgumentSeparator
+		}
+	case argumentText:
+		value = strings.ReplaceAll(value, ",", CommaSubstitute)
+	}
+
+	if len(value) > MaxArgumentLength {
+		return "", ErrArgumentTooLong
+	}
+
+	if !utf8.ValidString(value) {
+		return "", ErrNonPrintableArgument
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return "", ErrNonPrintableArgument
+		}
+	}
+
+	return value, nil
+}
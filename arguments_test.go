@@ -0,0 +1,179 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestArguments(t *testing.T) {
+	const id = "76561198000000001"
+	announcements := func(w *rcontest.World) any { return w.Announcements }
+	kicks := func(w *rcontest.World) any { return w.Kicks }
+	messages := func(w *rcontest.World) any { return w.DirectMessages }
+
+	tests := []struct {
+		name string
+		run  func(client *rcon.Client) error
+		// sent returns what the server received. It is compared with
+		// wantSent unless it is nil.
+		sent     func(w *rcontest.World) any
+		wantSent any
+		wantErr  error
+		// wantIndex is the index of the rejected argument.
+		wantIndex int
+	}{
+		{
+			name:     "plain text",
+			run:      func(c *rcon.Client) error { return c.Announce("Restart in 5 min") },
+			sent:     announcements,
+			wantSent: []string{"Restart in 5 min"},
+		},
+		{
+			name:     "comma in text",
+			run:      func(c *rcon.Client) error { return c.Announce("Restart in 5 min, save now") },
+			sent:     announcements,
+			wantSent: []string{"Restart in 5 min" + rcon.CommaSubstitute + " save now"},
+		},
+		{
+			name:     "comma in reason",
+			run:      func(c *rcon.Client) error { return c.KickPlayer(id, "AFK, again") },
+			sent:     kicks,
+			wantSent: []rcontest.Kick{{PlayerID: id, Reason: "AFK" + rcon.CommaSubstitute + " again"}},
+		},
+		{
+			name:     "comma in message",
+			run:      func(c *rcon.Client) error { return c.SendDirectMessage(id, "a,b") },
+			sent:     messages,
+			wantSent: []rcontest.DirectMessage{{PlayerID: id, Message: "a" + rcon.CommaSubstitute + "b"}},
+		},
+		{
+			name:     "longest text",
+			run:      func(c *rcon.Client) error { return c.Announce(strings.Repeat("x", rcon.MaxArgumentLength)) },
+			sent:     announcements,
+			wantSent: []string{strings.Repeat("x", rcon.MaxArgumentLength)},
+		},
+		{
+			name:     "text too long",
+			run:      func(c *rcon.Client) error { return c.Announce(strings.Repeat("x", rcon.MaxArgumentLength+1)) },
+			sent:     announcements,
+			wantSent: []string(nil),
+			wantErr:  rcon.ErrArgumentTooLong,
+		},
+		{
+			name:     "too long after replacing commas",
+			run:      func(c *rcon.Client) error { return c.Announce(strings.Repeat(",", rcon.MaxArgumentLength/2)) },
+			sent:     announcements,
+			wantSent: []string(nil),
+			wantErr:  rcon.ErrArgumentTooLong,
+		},
+		{
+			name:     "newline",
+			run:      func(c *rcon.Client) error { return c.Announce("line 1\nline 2") },
+			sent:     announcements,
+			wantSent: []string(nil),
+			wantErr:  rcon.ErrNonPrintableArgument,
+		},
+		{
+			name:     "NUL byte",
+			run:      func(c *rcon.Client) error { return c.Announce("a\x00b") },
+			sent:     announcements,
+			wantSent: []string(nil),
+			wantErr:  rcon.ErrNonPrintableArgument,
+		},
+		{
+			name:     "invalid UTF-8",
+			run:      func(c *rcon.Client) error { return c.Announce("a\xffb") },
+			sent:     announcements,
+			wantSent: []string(nil),
+			wantErr:  rcon.ErrNonPrintableArgument,
+		},
+		{
+			name:     "unicode",
+			run:      func(c *rcon.Client) error { return c.Announce("Grüße 🦖") },
+			sent:     announcements,
+			wantSent: []string{"Grüße 🦖"},
+		},
+		{
+			name:     "invalid player ID",
+			run:      func(c *rcon.Client) error { return c.KickPlayer("76561198000000001,x", "AFK") },
+			sent:     kicks,
+			wantSent: []rcontest.Kick(nil),
+			wantErr:  rcon.ErrInvalidPlayerID,
+		},
+		{
+			name:      "invalid message",
+			run:       func(c *rcon.Client) error { return c.SendDirectMessage(id, "\x07") },
+			sent:      messages,
+			wantSent:  []rcontest.DirectMessage(nil),
+			wantErr:   rcon.ErrNonPrintableArgument,
+			wantIndex: 1,
+		},
+		{
+			name:      "second player ID invalid",
+			run:       func(c *rcon.Client) error { return c.AddWhitelistID(id, "bob") },
+			sent:      func(w *rcontest.World) any { return w.Whitelist },
+			wantSent:  []rcon.PlayerID(nil),
+			wantErr:   rcon.ErrInvalidPlayerID,
+			wantIndex: 1,
+		},
+		{
+			name: "comma in name",
+			run: func(c *rcon.Client) error {
+				return c.UpdatePlayables([]rcon.DinoClass{rcon.Troodon, "Troodon,Stegosaurus"})
+			},
+			wantErr:   rcon.ErrArgumentSeparator,
+			wantIndex: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t)
+
+			err := tt.run(client)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var argErr *rcon.ArgumentError
+				if !errors.As(err, &argErr) {
+					t.Fatalf("error %v is not an *ArgumentError", err)
+				}
+				if argErr.Index != tt.wantIndex {
+					t.Errorf("argument %d was rejected, want %d", argErr.Index, tt.wantIndex)
+				}
+			}
+
+			if tt.sent == nil {
+				return
+			}
+			var sent any
+			server.Update(func(world *rcontest.World) { sent = tt.sent(world) })
+			if !reflect.DeepEqual(sent, tt.wantSent) {
+				t.Errorf("server received %q, want %q", sent, tt.wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/butt4cak3/theislercon/internal/parser"
+)
+
+// An Exchange is one request and the response to it, as recorded by a
+// [Recorder].
+type Exchange struct {
+	// Offset is the time between the start of the recording and the
+	// moment the request was sent.
+	Offset time.Duration
+	// Duration is the time it took to receive the complete response.
+	Duration time.Duration
+	Request  []byte
+	Response []byte
+	// Err is the error message, if the exchange failed.
+	Err string
+}
+
+// A Recorder writes the requests and responses of a [Client] to a capture.
+// Captures can be read with [ReadCapture] and served again by the replay
+// server in the rcontest package.
+//
+// The capture is a text format with one exchange per line:
+//
+//	<offset> <duration> <request> <response> [<error>]
+//
+// Durations are formatted like [time.Duration.String] and the other
+// fields are Go string literals, so that every byte of the response is
+// preserved. Lines starting with # are comments.
+//
+// The password of authentication requests is never written to the capture,
+// and neither is the server password in responses to GetServerDetails. It
+// is replaced with "REDACTED". Other responses are recorded as they
+// are.
+type Recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+}
+
+// NewRecorder returns a Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	recorder := &Recorder{w: w, start: time.Now()}
+	_, recorder.err = fmt.Fprintf(w, "# theislercon capture, started %s\n", recorder.start.Format(time.RFC3339))
+	return recorder
+}
+
+// Err returns the first error that occurred while writing the capture.
+func (recorder *Recorder) Err() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.err
+}
+
+func (recorder *Recorder) record(start time.Time, req, res []byte, err error) {
+	duration := time.Since(start)
+
+	if len(req) > 0 && req[0] == Auth {
+		req = []byte{Auth}
+	}
+	if len(req) > 1 && req[0] == ExecCommand && MessageType(req[1]) == GetServerDetails {
+		res = redactServerPassword(res)
+	}
+
+	line := fmt.Sprintf("%s %s %s %s", start.Sub(recorder.start), duration, strconv.Quote(string(req)), strconv.Quote(string(res)))
+	if err != nil {
+		line += " " + strconv.Quote(err.Error())
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.err != nil {
+		return
+	}
+	_, recorder.err = io.WriteString(recorder.w, line+"\n")
+}
+
+// redactServerPassword replaces the value of the ServerPassword key in a
+// GetServerDetails response. Like the parser, it assumes that the value
+// ends at the next comma. An empty password is left empty.
+func redactServerPassword(res []byte) []byte {
+	const key = "ServerPassword:"
+	for pos := 0; ; {
+		i := bytes.Index(res[pos:], []byte(key))
+		if i < 0 {
+			return res
+		}
+		start := pos + i
+		pos = start + len(key)
+		// bServerPassword contains the key, too.
+		if start > 0 && (parser.IsAsciiLetter(res[start-1]) || parser.IsAsciiDigit(res[start-1]) || res[start-1] == '_') {
+			continue
+		}
+
+		for pos < len(res) && res[pos] == ' ' {
+			pos++
+		}
+		end := pos
+		for end < len(res) && res[end] != ',' {
+			end++
+		}
+		if end == pos {
+			return res
+		}
+		return slices.Concat(res[:pos], []byte("REDACTED"), res[end:])
+	}
+}
+
+// ReadCapture reads all exchanges of a capture written by a [Recorder].
+func ReadCapture(r io.Reader) ([]Exchange, error) {
+	var exchanges []Exchange
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*DefaultMaxResponseSize)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		exchange, err := parseCaptureLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("capture line %d: %w", lineNumber, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exchanges, nil
+}
+
+func parseCaptureLine(line string) (Exchange, error) {
+	var exchange Exchange
+	var err error
+
+	offset, line, _ := strings.Cut(line, " ")
+	exchange.Offset, err = time.ParseDuration(offset)
+	if err != nil {
+		return exchange, err
+	}
+
+	duration, line, _ := strings.Cut(line, " ")
+	exchange.Duration, err = time.ParseDuration(duration)
+	if err != nil {
+		return exchange, err
+	}
+
+	req, line, err := unquotePrefix(line)
+	if err != nil {
+		return exchange, fmt.Errorf("request: %w", err)
+	}
+	exchange.Request = []byte(req)
+
+	res, line, err := unquotePrefix(line)
+	if err != nil {
+		return exchange, fmt.Errorf("response: %w", err)
+	}
+	exchange.Response = []byte(res)
+
+	if line != "" {
+		exchange.Err, _, err = unquotePrefix(line)
+		if err != nil {
+			return exchange, fmt.Errorf("error: %w", err)
+		}
+	}
+
+	return exchange, nil
+}
+
+// unquotePrefix reads a Go string literal from the start of s and
+// returns its value and the rest of s.
+func unquotePrefix(s string) (string, string, error) {
+	s = strings.TrimLeft(s, " ")
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", "", err
+	}
+	return value, s[len(quoted):], nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := rcon.NewRecorder(&buf)
+	server, client := connect(t, rcon.WithRecorder(recorder))
+
+	if err := client.Announce("hello"); err != nil {
+		t.Fatalf("Announce: %v", err)
+	}
+	server.SetDelay(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.SaveContext(ctx); err == nil {
+		t.Fatal("Save succeeded, want a timeout")
+	}
+
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recorder.Err() = %v", err)
+	}
+	if strings.Contains(buf.String(), "password") {
+		t.Errorf("capture contains the password:\n%s", buf.String())
+	}
+
+	exchanges, err := rcon.ReadCapture(&buf)
+	if err != nil {
+		t.Fatalf("ReadCapture: %v", err)
+	}
+
+	want := []struct {
+		request  string
+		response string
+		failed   bool
+	}{
+		{"\x01", "Password Accepted", false},
+		{"\x02\x10hello", "Announce hello", false},
+		{"\x02\x50", "", true},
+	}
+	if len(exchanges) != len(want) {
+		t.Fatalf("got %d exchanges, want %d: %+v", len(exchanges), len(want), exchanges)
+	}
+	var prev time.Duration
+	for i, exchange := range exchanges {
+		if string(exchange.Request) != want[i].request {
+			t.Errorf("exchange %d: request %q, want %q", i+1, exchange.Request, want[i].request)
+		}
+		if !strings.HasSuffix(string(exchange.Response), want[i].response) {
+			t.Errorf("exchange %d: response %q, want it to end with %q", i+1, exchange.Response, want[i].response)
+		}
+		if failed := exchange.Err != ""; failed != want[i].failed {
+			t.Errorf("exchange %d: error %q", i+1, exchange.Err)
+		}
+		if exchange.Offset < prev {
+			t.Errorf("exchange %d: offset %v is before the previous one", i+1, exchange.Offset)
+		}
+		prev = exchange.Offset
+	}
+}
+
+func TestRecorderServerPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"password", "hunter2", "REDACTED"},
+		{"password with spaces", "correct horse", "REDACTED"},
+		{"no password", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			server, client := connect(t, rcon.WithRecorder(rcon.NewRecorder(&buf)))
+			server.Update(func(world *rcontest.World) { world.Details.Password = tt.password })
+
+			if _, err := client.GetServerDetails(); err != nil {
+				t.Fatalf("GetServerDetails: %v", err)
+			}
+			if tt.password != "" && strings.Contains(buf.String(), tt.password) {
+				t.Errorf("capture contains the server password:\n%s", buf.String())
+			}
+
+			exchanges, err := rcon.ReadCapture(&buf)
+			if err != nil {
+				t.Fatalf("ReadCapture: %v", err)
+			}
+			if len(exchanges) != 2 {
+				t.Fatalf("got %d exchanges, want 2", len(exchanges))
+			}
+
+			// The recorded response still parses, so it can be replayed.
+			replayed := replay(t, string(exchanges[1].Request), string(exchanges[1].Response))
+			details, err := replayed.GetServerDetails()
+			if err != nil {
+				t.Fatalf("GetServerDetails from the capture: %v", err)
+			}
+			if details.Password != tt.want {
+				t.Errorf("Password = %q, want %q", details.Password, tt.want)
+			}
+			if details.HasPassword != (tt.password != "") {
+				t.Errorf("HasPassword = %v, want %v", details.HasPassword, tt.password != "")
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecorderErr(t *testing.T) {
+	recorder := rcon.NewRecorder(failingWriter{})
+	_, client := connect(t, rcon.WithRecorder(recorder))
+
+	// The client keeps working when the capture cannot be written.
+	if err := client.Announce("hello"); err != nil {
+		t.Fatalf("Announce: %v", err)
+	}
+	if err := recorder.Err(); err == nil {
+		t.Error("recorder.Err() = nil, want an error")
+	}
+}
+
+func TestReadCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture string
+		want    []rcon.Exchange
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			capture: "",
+			want:    nil,
+		},
+		{
+			name:    "comments and blank lines",
+			capture: "# header\n\n   \n# another comment\n",
+			want:    nil,
+		},
+		{
+			name:    "exchange",
+			capture: `1.5s 20ms "\x02\x40" "[2025.01.01-00.00.00] PlayerList\n76561198000000001,Alice,,\n"` + "\n",
+			want: []rcon.Exchange{{
+				Offset:   1500 * time.Millisecond,
+				Duration: 20 * time.Millisecond,
+				Request:  []byte("\x02\x40"),
+				Response: []byte("[2025.01.01-00.00.00] PlayerList\n76561198000000001,Alice,,\n"),
+			}},
+		},
+		{
+			name:    "failed exchange",
+			capture: `0s 5s "\x02\x50" "" "i/o timeout"` + "\n",
+			want: []rcon.Exchange{{
+				Duration: 5 * time.Second,
+				Request:  []byte("\x02\x50"),
+				Response: []byte{},
+				Err:      "i/o timeout",
+			}},
+		},
+		{
+			name:    "NUL bytes",
+			capture: `0s 1ms "\x00" "Password Accepted\x00"` + "\n",
+			want: []rcon.Exchange{{
+				Duration: time.Millisecond,
+				Request:  []byte("\x00"),
+				Response: []byte("Password Accepted\x00"),
+			}},
+		},
+		{
+			name:    "invalid offset",
+			capture: "# header\nsoon 1ms \"\\x00\" \"\"\n",
+			wantErr: "capture line 2",
+		},
+		{
+			name:    "unquoted request",
+			capture: "0s 1ms request \"\"\n",
+			wantErr: "capture line 1: request",
+		},
+		{
+			name:    "missing response",
+			capture: "0s 1ms \"\\x00\"\n",
+			wantErr: "capture line 1: response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rcon.ReadCapture(strings.NewReader(tt.capture))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadCapture: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCapture() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
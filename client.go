@@ -18,36 +18,127 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package theislercon
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/butt4cak3/theislercon/internal/parser"
 )
 
 // The Client type contains methods for all RCON commands.
+//
+// Every method has a variant with a Context suffix that takes a
+// [context.Context]. Cancelling the context or letting its deadline pass
+// aborts the call, regardless of whether the client is still waiting for
+// its turn, writing the request or waiting for the response. The methods
+// without the suffix use [context.Background].
 type Client struct {
-	conn  net.Conn
-	mutex sync.Mutex
+	conn net.Conn
+	// sem guards the connection. Only one request may be in flight at a time.
+	sem chan struct{}
+
+	// connMu protects conn and closed against concurrent calls to Close.
+	// Code that holds sem may read conn without locking connMu.
+	connMu sync.Mutex
+	closed bool
+
+	// broken is set when an exchange failed in a way that leaves the
+	// connection in an unknown state.
+	broken bool
+
+	// unterminated is set when the last response ended with the idle gap
+	// rather than a NULL byte. More data of it may still arrive.
+	unterminated bool
+
+	addr      string
+	password  string
+	reconnect *ReconnectPolicy
+
+	maxResponseSize int
+	dial            DialFunc
+	dialTimeout     time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleGap         time.Duration
+	logger          *slog.Logger
+	recorder        *Recorder
+	location        *time.Location
+
+	strictServerDetails bool
+
+	// optionErr is the first invalid option passed to Connect.
+	optionErr error
+
+	// queue orders the commands if [WithQueue] is used.
+	queue *commandQueue
+
+	stats statsCollector
 }
 
 // Connect tries to connect to the specified address.
-func Connect(addr string) (*Client, error) {
-	client := new(Client)
-	err := client.connect(addr)
+//
+// The behavior of the client can be customized with options like
+// [WithDialTimeout] or [WithLogger].
+func Connect(addr string, opts ...Option) (*Client, error) {
+	return ConnectContext(context.Background(), addr, opts...)
+}
+
+// ConnectContext tries to connect to the specified address.
+//
+// The context only applies to establishing the connection. Once
+// ConnectContext returns, cancelling ctx has no effect on the client.
+func ConnectContext(ctx context.Context, addr string, opts ...Option) (*Client, error) {
+	client := newClient(addr, opts)
+	if client.optionErr != nil {
+		return nil, client.optionErr
+	}
+	conn, err := client.dialContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	client.conn = conn
 	return client, nil
 }
 
-func (client *Client) connect(addr string) error {
-	conn, err := net.Dial("tcp", addr)
+func newClient(addr string, opts []Option) *Client {
+	var dialer net.Dialer
+	client := &Client{
+		sem:             make(chan struct{}, 1),
+		addr:            addr,
+		maxResponseSize: DefaultMaxResponseSize,
+		dial:            dialer.DialContext,
+		readTimeout:     DefaultTimeout,
+		writeTimeout:    DefaultTimeout,
+		idleGap:         DefaultIdleGap,
+		logger:          slog.New(slog.DiscardHandler),
+		location:        time.UTC,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// dialContext opens a new connection to the address of the client.
+func (client *Client) dialContext(ctx context.Context) (net.Conn, error) {
+	if client.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.dialTimeout)
+		defer cancel()
+	}
+	conn, err := client.dial(ctx, "tcp", client.addr)
 	if err != nil {
-		return err
+		client.logger.Debug("rcon dial failed", "addr", client.addr, "error", err)
+		return nil, err
 	}
-	client.conn = conn
-	return nil
+	client.logger.Debug("rcon connected", "addr", client.addr)
+	return conn, nil
 }
 
 // Auth tries to authenticate with the gameserver.
@@ -55,19 +146,22 @@ func (client *Client) connect(addr string) error {
 // If the reason for failure is an incorrect password, the function
 // will return [ErrIncorrectPassword].
 func (client *Client) Auth(password string) error {
+	return client.AuthContext(context.Background(), password)
+}
+
+// AuthContext is like [Client.Auth], but takes a context.
+func (client *Client) AuthContext(ctx context.Context, password string) error {
 	msg := make([]byte, 0, 100)
 	msg = append(msg, Auth)
 	msg = append(msg, password...)
 
-	client.mutex.Lock()
-	defer client.mutex.Unlock()
-
-	err := client.send(msg)
+	err := client.lock(ctx)
 	if err != nil {
 		return err
 	}
+	defer client.unlock()
 
-	res, err := client.recv()
+	res, err := client.roundTrip(ctx, msg, true)
 	if err != nil {
 		return err
 	}
@@ -75,6 +169,9 @@ func (client *Client) Auth(password string) error {
 	response := string(res)
 
 	if response == "Password Accepted" {
+		// Remember the password so that the client can authenticate
+		// again after reconnecting.
+		client.password = password
 		return nil
 	} else {
 		return ErrIncorrectPassword
@@ -87,46 +184,50 @@ func (client *Client) Auth(password string) error {
 // connected, but not playing (i.e. players that are in the class selection
 // screen). However, the list only contains IDs and names.
 func (client *Client) GetPlayerList() ([]Player, error) {
-	msg, err := client.ExecCommand(GetPlayerList)
-	if err != nil {
-		return nil, err
-	}
+	return client.GetPlayerListContext(context.Background())
+}
 
-	response, err := parseResponse(msg, "PlayerList")
+// GetPlayerListContext is like [Client.GetPlayerList], but takes a context.
+func (client *Client) GetPlayerListContext(ctx context.Context) ([]Player, error) {
+	msg, err := client.ExecCommandContext(ctx, GetPlayerList)
 	if err != nil {
 		return nil, err
 	}
 
-	msg = response.Content
-
-	lines := strings.Split(msg, ",")
-	players := make([]Player, 0, len(lines)/3)
-
-	for i := 0; i < len(lines); i += 3 {
-		playerID := strings.TrimSpace(lines[i])
-		if playerID == "" {
-			break
-		}
-		name := strings.TrimSpace(lines[i+1])
-		players = append(players, Player{ID: playerID, Name: name})
-	}
-
-	return players, nil
+	return parsePlayerList(msg)
 }
 
 // Announce sends a message to all currently connected players.
 //
 // The message will be displayed in a large text box at the top of the screen.
 func (client *Client) Announce(message string) error {
-	_, err := client.ExecCommand(Announce, message)
+	return client.AnnounceContext(context.Background(), message)
+}
+
+// AnnounceContext is like [Client.Announce], but takes a context.
+func (client *Client) AnnounceContext(ctx context.Context, message string) error {
+	params, err := encodeArguments(Announce, textArgument(message))
+	if err != nil {
+		return err
+	}
+	_, err = client.ExecCommandContext(ctx, Announce, params...)
 	return err
 }
 
 // SendDirectMessage sends an announcement message to one specific user.
 //
 // The message will be shown in the same way as a regular announcement.
-func (client *Client) SendDirectMessage(playerID, message string) error {
-	_, err := client.ExecCommand(DirectMessage, playerID, message)
+func (client *Client) SendDirectMessage(playerID PlayerID, message string) error {
+	return client.SendDirectMessageContext(context.Background(), playerID, message)
+}
+
+// SendDirectMessageContext is like [Client.SendDirectMessage], but takes a context.
+func (client *Client) SendDirectMessageContext(ctx context.Context, playerID PlayerID, message string) error {
+	params, err := encodeArguments(DirectMessage, idArgument(playerID), textArgument(message))
+	if err != nil {
+		return err
+	}
+	_, err = client.ExecCommandContext(ctx, DirectMessage, params...)
 	return err
 }
 
@@ -136,22 +237,75 @@ func (client *Client) SendDirectMessage(playerID, message string) error {
 // structs and not just IDs and names. However, it excludes players that
 // are still in the class selection screen.
 func (client *Client) GetPlayerData() ([]Player, error) {
-	msg, err := client.ExecCommand(GetPlayerData)
+	return client.GetPlayerDataContext(context.Background())
+}
+
+// GetPlayerDataContext is like [Client.GetPlayerData], but takes a context.
+func (client *Client) GetPlayerDataContext(ctx context.Context) ([]Player, error) {
+	msg, err := client.ExecCommandContext(ctx, GetPlayerData)
 	if err != nil {
 		return nil, err
 	}
 
-	return parsePlayerDataMessage(msg)
+	players, _, err := parsePlayerDataMessage(msg, client.location)
+	return players, err
+}
+
+// GetPlayerDataWithTimestamp is like [Client.GetPlayerData], but also
+// returns the time of the server when it sent the data. The time is zero
+// if the server did not send a timestamp.
+//
+// The time zone of the server can be set with [WithServerLocation].
+func (client *Client) GetPlayerDataWithTimestamp() ([]Player, time.Time, error) {
+	return client.GetPlayerDataWithTimestampContext(context.Background())
+}
+
+// GetPlayerDataWithTimestampContext is like
+// [Client.GetPlayerDataWithTimestamp], but takes a context.
+func (client *Client) GetPlayerDataWithTimestampContext(ctx context.Context) ([]Player, time.Time, error) {
+	msg, err := client.ExecCommandContext(ctx, GetPlayerData)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return parsePlayerDataMessage(msg, client.location)
+}
+
+// GetPlayerDataLenient is like [Client.GetPlayerData], but does not fail
+// if some of the player records cannot be parsed. Those records are
+// skipped and the reasons are returned as a list of [*PlayerDataError].
+//
+// An error is only returned if the request failed or the response is not
+// a PlayerData response at all.
+func (client *Client) GetPlayerDataLenient() ([]Player, []*PlayerDataError, error) {
+	return client.GetPlayerDataLenientContext(context.Background())
+}
+
+// GetPlayerDataLenientContext is like [Client.GetPlayerDataLenient], but
+// takes a context.
+func (client *Client) GetPlayerDataLenientContext(ctx context.Context) ([]Player, []*PlayerDataError, error) {
+	msg, err := client.ExecCommandContext(ctx, GetPlayerData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, players, errs, err := parsePlayerData(msg, true, client.location)
+	return players, errs, err
 }
 
 // GetServerDetails returns some information about the server.
 func (client *Client) GetServerDetails() (*ServerDetails, error) {
-	msg, err := client.ExecCommand(GetServerDetails)
+	return client.GetServerDetailsContext(context.Background())
+}
+
+// GetServerDetailsContext is like [Client.GetServerDetails], but takes a context.
+func (client *Client) GetServerDetailsContext(ctx context.Context) (*ServerDetails, error) {
+	msg, err := client.ExecCommandContext(ctx, GetServerDetails)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := parseServerDetails(msg)
+	result, err := parseServerDetails(msg, client.location, client.strictServerDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -161,45 +315,136 @@ func (client *Client) GetServerDetails() (*ServerDetails, error) {
 
 // WipeCorpses removes all dead entities from the map.
 func (client *Client) WipeCorpses() error {
-	_, err := client.ExecCommand(WipeCorpses)
+	return client.WipeCorpsesContext(context.Background())
+}
+
+// WipeCorpsesContext is like [Client.WipeCorpses], but takes a context.
+func (client *Client) WipeCorpsesContext(ctx context.Context) error {
+	_, err := client.ExecCommandContext(ctx, WipeCorpses)
 	return err
 }
 
 // UpdatePlayables defines the list of playable classes.
 func (client *Client) UpdatePlayables(classes []DinoClass) error {
-	classNames := make([]string, len(classes))
+	return client.UpdatePlayablesContext(context.Background(), classes)
+}
+
+// UpdatePlayablesContext is like [Client.UpdatePlayables], but takes a context.
+func (client *Client) UpdatePlayablesContext(ctx context.Context, classes []DinoClass) error {
+	args := make([]argument, len(classes))
 	for i, class := range classes {
-		classNames[i] = string(class)
+		args[i] = nameArgument(string(class))
+	}
+	params, err := encodeArguments(UpdatePlayables, args...)
+	if err != nil {
+		return err
 	}
-	_, err := client.ExecCommand(UpdatePlayables, classNames...)
+	_, err = client.ExecCommandContext(ctx, UpdatePlayables, params...)
 	return err
 }
 
 // KickPlayer kicks the player from the server.
-func (client *Client) KickPlayer(playerID, reason string) error {
-	_, err := client.ExecCommand(KickPlayer, playerID, reason)
+func (client *Client) KickPlayer(playerID PlayerID, reason string) error {
+	return client.KickPlayerContext(context.Background(), playerID, reason)
+}
+
+// KickPlayerContext is like [Client.KickPlayer], but takes a context.
+func (client *Client) KickPlayerContext(ctx context.Context, playerID PlayerID, reason string) error {
+	params, err := encodeArguments(KickPlayer, idArgument(playerID), textArgument(reason))
+	if err != nil {
+		return err
+	}
+	_, err = client.ExecCommandContext(ctx, KickPlayer, params...)
 	return err
 }
 
+// BanPlayer bans the player from the server.
+//
+// The server only accepts durations in whole minutes, so duration is
+// rounded up to the next minute. A duration of zero bans the player
+// permanently.
+//
+// If the server rejects the ban, the returned error wraps
+// [ErrCommandFailed] and contains the message of the server.
+//
+// The order of the arguments (player ID, reason, minutes) has not been
+// verified against a real server yet.
+func (client *Client) BanPlayer(playerID PlayerID, reason string, duration time.Duration) error {
+	return client.BanPlayerContext(context.Background(), playerID, reason, duration)
+}
+
+// BanPlayerContext is like [Client.BanPlayer], but takes a context.
+func (client *Client) BanPlayerContext(ctx context.Context, playerID PlayerID, reason string, duration time.Duration) error {
+	if duration < 0 {
+		return fmt.Errorf("negative ban duration %s", duration)
+	}
+
+	minutes := duration / time.Minute
+	if duration%time.Minute != 0 {
+		minutes++
+	}
+
+	params, err := encodeArguments(BanPlayer, idArgument(playerID), textArgument(reason), nameArgument(strconv.FormatInt(int64(minutes), 10)))
+	if err != nil {
+		return err
+	}
+
+	msg, err := client.ExecCommandContext(ctx, BanPlayer, params...)
+	if err != nil {
+		return err
+	}
+
+	response, err := parseAcknowledgement(BanPlayer, msg, "BanPlayer", client.location)
+	if err != nil {
+		return err
+	}
+
+	// The server echoes the arguments as it received them, i.e. encoded.
+	return checkAcknowledgement(response.Content, params[0], params[1])
+}
+
 // Save saves the current state of the map.
 func (client *Client) Save() error {
-	_, err := client.ExecCommand(Save)
+	return client.SaveContext(context.Background())
+}
+
+// SaveContext is like [Client.Save], but takes a context.
+func (client *Client) SaveContext(ctx context.Context) error {
+	_, err := client.ExecCommandContext(ctx, Save)
 	return err
 }
 
 // ToggleWhitelist turns the whielist on or off and returns true, if the new state is on.
 func (client *Client) ToggleWhitelist() (bool, error) {
-	res, err := client.ExecCommand(ToggleWhitelist)
+	return client.ToggleWhitelistContext(context.Background())
+}
+
+// ToggleWhitelistContext is like [Client.ToggleWhitelist], but takes a context.
+func (client *Client) ToggleWhitelistContext(ctx context.Context) (bool, error) {
+	res, err := client.ExecCommandContext(ctx, ToggleWhitelist)
 	if err != nil {
 		return false, err
 	}
-	return onOff(res[len(res)-2:]), nil
+	return parseToggle(ToggleWhitelist, res)
 }
 
 // AddWhitelistID adds one or more PlayerIDs to the whitelist.
-func (client *Client) AddWhitelistID(playerID ...string) error {
+func (client *Client) AddWhitelistID(playerID ...PlayerID) error {
+	return client.AddWhitelistIDContext(context.Background(), playerID...)
+}
+
+// AddWhitelistIDContext is like [Client.AddWhitelistID], but takes a context.
+func (client *Client) AddWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error {
 	if len(playerID) > 0 {
-		_, err := client.ExecCommand(AddWhitelistID, playerID...)
+		args := make([]argument, len(playerID))
+		for i, id := range playerID {
+			args[i] = idArgument(id)
+		}
+		params, err := encodeArguments(AddWhitelistID, args...)
+		if err != nil {
+			return err
+		}
+		_, err = client.ExecCommandContext(ctx, AddWhitelistID, params...)
 		return err
 	} else {
 		return nil
@@ -207,9 +452,22 @@ func (client *Client) AddWhitelistID(playerID ...string) error {
 }
 
 // RemoveWhitelistID removes one or more PlayerIDs from the whitelist.
-func (client *Client) RemoveWhitelistID(playerID ...string) error {
+func (client *Client) RemoveWhitelistID(playerID ...PlayerID) error {
+	return client.RemoveWhitelistIDContext(context.Background(), playerID...)
+}
+
+// RemoveWhitelistIDContext is like [Client.RemoveWhitelistID], but takes a context.
+func (client *Client) RemoveWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error {
 	if len(playerID) > 0 {
-		_, err := client.ExecCommand(RemoveWhitelistID, playerID...)
+		args := make([]argument, len(playerID))
+		for i, id := range playerID {
+			args[i] = idArgument(id)
+		}
+		params, err := encodeArguments(RemoveWhitelistID, args...)
+		if err != nil {
+			return err
+		}
+		_, err = client.ExecCommandContext(ctx, RemoveWhitelistID, params...)
 		return err
 	} else {
 		return nil
@@ -221,50 +479,90 @@ func (client *Client) RemoveWhitelistID(playerID ...string) error {
 // If you need to know whether global chat is already enabled,
 // use [Client.GetServerDetails].
 func (client *Client) ToggleGlobalChat() (bool, error) {
-	res, err := client.ExecCommand(ToggleGlobalChat)
+	return client.ToggleGlobalChatContext(context.Background())
+}
+
+// ToggleGlobalChatContext is like [Client.ToggleGlobalChat], but takes a context.
+func (client *Client) ToggleGlobalChatContext(ctx context.Context) (bool, error) {
+	res, err := client.ExecCommandContext(ctx, ToggleGlobalChat)
 	if err != nil {
 		return false, err
 	}
-	return onOff(res[len(res)-2:]), nil
+	return parseToggle(ToggleGlobalChat, res)
 }
 
 // ToggleHumans turns on or off the humans feature in the game.
 func (client *Client) ToggleHumans() (bool, error) {
-	res, err := client.ExecCommand(ToggleHumans)
+	return client.ToggleHumansContext(context.Background())
+}
+
+// ToggleHumansContext is like [Client.ToggleHumans], but takes a context.
+func (client *Client) ToggleHumansContext(ctx context.Context) (bool, error) {
+	res, err := client.ExecCommandContext(ctx, ToggleHumans)
 	if err != nil {
 		return false, err
 	}
-	return onOff(res[len(res)-2:]), nil
+	return parseToggle(ToggleHumans, res)
 }
 
 // ToggleAI turns the spawning of AI on or off.
 func (client *Client) ToggleAI() (bool, error) {
-	res, err := client.ExecCommand(ToggleAI)
+	return client.ToggleAIContext(context.Background())
+}
+
+// ToggleAIContext is like [Client.ToggleAI], but takes a context.
+func (client *Client) ToggleAIContext(ctx context.Context) (bool, error) {
+	res, err := client.ExecCommandContext(ctx, ToggleAI)
 	if err != nil {
 		return false, err
 	}
-	return onOff(res[len(res)-2:]), nil
+	return parseToggle(ToggleAI, res)
 }
 
 // DisableAIClasses defines the list of AI classes that cannot spawn.
 func (client *Client) DisableAIClasses(classes []AIClass) error {
-	classNames := make([]string, len(classes))
+	return client.DisableAIClassesContext(context.Background(), classes)
+}
+
+// DisableAIClassesContext is like [Client.DisableAIClasses], but takes a context.
+func (client *Client) DisableAIClassesContext(ctx context.Context, classes []AIClass) error {
+	args := make([]argument, len(classes))
 	for i, class := range classes {
-		classNames[i] = string(class)
+		args[i] = nameArgument(string(class))
+	}
+	params, err := encodeArguments(DisableAIClasses, args...)
+	if err != nil {
+		return err
 	}
-	_, err := client.ExecCommand(DisableAIClasses, classNames...)
+	_, err = client.ExecCommandContext(ctx, DisableAIClasses, params...)
 	return err
 }
 
 // SetAIDensity sets the AI density that can also be defined in Game.ini.
 func (client *Client) SetAIDensity(density float32) error {
-	_, err := client.ExecCommand(SetAIDensity, fmt.Sprintf("%.3f", density))
+	return client.SetAIDensityContext(context.Background(), density)
+}
+
+// SetAIDensityContext is like [Client.SetAIDensity], but takes a context.
+func (client *Client) SetAIDensityContext(ctx context.Context, density float32) error {
+	_, err := client.ExecCommandContext(ctx, SetAIDensity, fmt.Sprintf("%.3f", density))
 	return err
 }
 
 // Close closes the connection to the server.
+//
+// After Close, the client does not reconnect anymore and every method
+// returns [net.ErrClosed].
 func (client *Client) Close() error {
-	return client.conn.Close()
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	client.closed = true
+	err := client.conn.Close()
+	if errors.Is(err, net.ErrClosed) {
+		// The connection broke earlier and was closed then.
+		return nil
+	}
+	return err
 }
 
 // ExecCommand formats a command and sends it to the server.
@@ -277,8 +575,16 @@ func (client *Client) Close() error {
 // While command can be any byte, you should normally use one of the
 // [MessageType] constants.
 //
+// The parameters are sent as they are, separated by commas. Unlike the
+// other methods of the client, ExecCommand does not check or encode them.
+//
 // This function returns the server's response as a string.
 func (client *Client) ExecCommand(command byte, params ...string) (string, error) {
+	return client.ExecCommandContext(context.Background(), command, params...)
+}
+
+// ExecCommandContext is like [Client.ExecCommand], but takes a context.
+func (client *Client) ExecCommandContext(ctx context.Context, command byte, params ...string) (string, error) {
 	cmd := make([]byte, 0, 1024)
 	cmd = append(cmd, ExecCommand)
 	cmd = append(cmd, command)
@@ -289,21 +595,178 @@ func (client *Client) ExecCommand(command byte, params ...string) (string, error
 		cmd = append(cmd, param...)
 	}
 
-	// We only ever want to send one request over this connection at a time.
-	client.mutex.Lock()
-	defer client.mutex.Unlock()
-
-	err := client.send(cmd)
+	var res []byte
+	var err error
+	if client.queue != nil {
+		res, err = client.queue.exec(ctx, command, cmd)
+	} else {
+		res, err = client.execNow(ctx, command, cmd)
+	}
 	if err != nil {
+		client.logger.Debug("rcon command failed", "command", command, "error", err)
 		return "", err
 	}
 
-	res, err := client.recv()
+	return string(res), nil
+}
+
+// execNow sends a request as soon as the connection is free.
+func (client *Client) execNow(ctx context.Context, command MessageType, msg []byte) ([]byte, error) {
+	err := client.lock(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer client.unlock()
 
-	return string(res), nil
+	return client.roundTrip(ctx, msg, isIdempotent(command))
+}
+
+// lock waits until no other request is in flight or ctx is done.
+func (client *Client) lock(ctx context.Context) error {
+	// We only ever want to send one request over this connection at a time.
+	select {
+	case client.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (client *Client) unlock() {
+	<-client.sem
+}
+
+// roundTrip sends a request and waits for the response to it.
+//
+// A broken connection is replaced before the request is sent: with the
+// [ReconnectPolicy] if reconnecting is enabled, otherwise with a single
+// attempt. If reconnecting is enabled, requests that are safe to repeat
+// are also sent again once after the connection broke during the
+// exchange.
+//
+// The caller must hold the lock.
+func (client *Client) roundTrip(ctx context.Context, msg []byte, idempotent bool) (res []byte, err error) {
+	start := time.Now()
+	defer func() {
+		client.stats.observe(requestCommand(msg), time.Since(start), err)
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if client.isClosed() {
+			return nil, net.ErrClosed
+		}
+
+		if !client.broken && client.lateData() {
+			// The previous response was cut short, and its rest would be
+			// taken for the response to this request.
+			client.logger.Warn("rcon response continued after the idle gap", "addr", client.addr)
+			client.broken = true
+		}
+
+		if client.broken {
+			var err error
+			if client.reconnect == nil {
+				err = client.reconnectOnce(ctx)
+			} else {
+				err = client.redial(ctx)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := client.exchange(ctx, msg)
+		if err == nil {
+			return res, nil
+		}
+
+		// Whatever happened, we cannot tell whether a response to this
+		// request is still going to arrive. A late response would be taken
+		// for the response to the next request, so the connection must not
+		// be used anymore.
+		client.broken = true
+
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
+		if client.reconnect == nil || !idempotent || attempt > 0 || client.isClosed() {
+			return nil, err
+		}
+	}
+}
+
+// exchange writes msg to the current connection and reads one response.
+//
+// The caller must hold the lock.
+func (client *Client) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	if client.recorder == nil {
+		return client.exchangeOnce(ctx, msg)
+	}
+
+	start := time.Now()
+	res, err := client.exchangeOnce(ctx, msg)
+	client.recorder.record(start, msg, res, err)
+	return res, err
+}
+
+func (client *Client) exchangeOnce(ctx context.Context, msg []byte) ([]byte, error) {
+	conn := client.conn
+
+	conn.SetWriteDeadline(deadlineFor(ctx, client.writeTimeout))
+
+	// Unblock any pending write or read as soon as the context is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	err := client.send(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := deadlineFor(ctx, client.readTimeout)
+	conn.SetReadDeadline(deadline)
+	// Setting the deadline may have undone the cancellation above.
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return client.recv(ctx, deadline)
+}
+
+// contextError returns the error of ctx. Because the deadline of ctx is
+// also set on the connection, the connection may time out slightly before
+// ctx reports it. In that case, contextError returns
+// [context.DeadlineExceeded] as well.
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// deadlineFor returns the earlier of now+timeout and the deadline of ctx.
+// A timeout of zero means no limit. If neither limits the time,
+// deadlineFor returns the zero time, which means no deadline.
+func deadlineFor(ctx context.Context, timeout time.Duration) time.Time {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	return deadline
+}
+
+func (client *Client) isClosed() bool {
+	client.connMu.Lock()
+	defer client.connMu.Unlock()
+	return client.closed
 }
 
 func (client *Client) send(msg []byte) error {
@@ -311,16 +774,53 @@ func (client *Client) send(msg []byte) error {
 	return err
 }
 
-func (client *Client) recv() ([]byte, error) {
-	buf := make([]byte, 1024*10)
-	client.conn.SetReadDeadline(time.Now().Add(time.Duration(5) * time.Second))
-	n, err := client.conn.Read(buf)
+// parseToggle returns the new state from the response to a toggle command.
+// The response ends with either "On" or "Off".
+func parseToggle(command MessageType, res string) (bool, error) {
+	trimmed := strings.TrimSpace(res)
+	if strings.HasSuffix(trimmed, "On") {
+		return true, nil
+	} else if strings.HasSuffix(trimmed, "Off") {
+		return false, nil
+	} else {
+		return false, newParseError(command, res, parser.Expected(`"On" or "Off"`, res[len(trimmed):]))
+	}
+}
+
+// parseAcknowledgement parses the response to a command that only
+// acknowledges it. Like the timestamp, the response type name is
+// optional.
+func parseAcknowledgement(command MessageType, msg string, responseType string, loc *time.Location) (*Response, error) {
+	response, err := parseResponse(command, msg, "", loc)
 	if err != nil {
 		return nil, err
 	}
-	return buf[:n], nil
+	if content, ok := strings.CutPrefix(response.Content, responseType); ok {
+		response.Type = responseType
+		response.Content = content
+	}
+	return response, nil
 }
 
-func onOff(s string) bool {
-	return s == "On"
+// checkAcknowledgement looks for signs of failure in the message that
+// the server sends after executing a command.
+//
+// The server may repeat the arguments of the command, so echoed is
+// removed from the message first. Otherwise, a reason like "error in
+// chat" would be taken for a failure.
+func checkAcknowledgement(content string, echoed ...string) error {
+	content = strings.TrimSpace(content)
+	status := content
+	for _, arg := range echoed {
+		if arg != "" {
+			status = strings.ReplaceAll(status, arg, "")
+		}
+	}
+	lower := strings.ToLower(status)
+	for _, word := range []string{"fail", "invalid", "not found", "error"} {
+		if strings.Contains(lower, word) {
+			return fmt.Errorf("%w: %s", ErrCommandFailed, content)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,277 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+// connect starts a fake server and returns an authenticated client.
+func connect(t *testing.T, opts ...rcon.Option) (*rcontest.Server, *rcon.Client) {
+	t.Helper()
+
+	server := rcontest.NewServer("password")
+	t.Cleanup(server.Close)
+
+	client, err := rcon.Connect(server.Addr, opts...)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	return server, client
+}
+
+// TestLateResponse checks that the response to a command that timed out
+// is never taken for the response to the next command.
+func TestLateResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []rcon.Option
+		wantAI  bool
+		wantErr error
+	}{
+		{
+			name:   "without reconnect",
+			wantAI: false,
+		},
+		{
+			name:   "with reconnect",
+			opts:   []rcon.Option{rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 1})},
+			wantAI: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, tt.opts...)
+
+			server.SetDelay(200 * time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if _, err := client.ToggleWhitelistContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("ToggleWhitelist: got error %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			// Let the late "Whitelist: On" arrive.
+			time.Sleep(300 * time.Millisecond)
+			server.SetDelay(0)
+
+			ai, err := client.ToggleAI()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ToggleAI: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && ai != tt.wantAI {
+				t.Errorf("ToggleAI: got %v, want %v", ai, tt.wantAI)
+			}
+		})
+	}
+}
+
+// TestReuseAfterAbort checks that a client without a ReconnectPolicy keeps
+// working after a command was aborted.
+func TestReuseAfterAbort(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []rcon.Option
+		abort func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name: "deadline",
+			abort: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+		},
+		{
+			name: "cancel",
+			abort: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+		},
+		{
+			name: "read timeout",
+			opts: []rcon.Option{rcon.WithReadTimeout(50 * time.Millisecond)},
+			abort: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, tt.opts...)
+			server.Update(func(world *rcontest.World) { world.AddPlayer(alice) })
+
+			server.SetDelay(200 * time.Millisecond)
+			ctx, cancel := tt.abort()
+			_, err := client.ToggleWhitelistContext(ctx)
+			cancel()
+			if err == nil {
+				t.Fatal("ToggleWhitelist succeeded, want it to be aborted")
+			}
+			server.SetDelay(0)
+
+			// The late response to the toggle must not be taken for the
+			// player list, and the new connection must be authenticated.
+			players, err := client.GetPlayerList()
+			if err != nil {
+				t.Fatalf("GetPlayerList after the abort: %v", err)
+			}
+			if len(players) != 1 || players[0].ID != alice.ID {
+				t.Errorf("GetPlayerList = %v, want only %v", players, alice.ID)
+			}
+			if _, err := client.ToggleAI(); err != nil {
+				t.Errorf("ToggleAI after the abort: %v", err)
+			}
+			if got := client.Stats().Reconnects; got != 1 {
+				t.Errorf("%d reconnects, want 1", got)
+			}
+		})
+	}
+}
+
+// replay starts a replay server that accepts the password and then
+// answers request with response, and returns an authenticated client.
+func replay(t *testing.T, request, response string) *rcon.Client {
+	t.Helper()
+
+	server := rcontest.NewReplayServer([]rcon.Exchange{
+		{Request: []byte{rcon.Auth}, Response: []byte("Password Accepted")},
+		{Request: []byte(request), Response: []byte(response)},
+	})
+	t.Cleanup(func() {
+		server.Close()
+		if err := server.Err(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	client, err := rcon.Connect(server.Addr)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	return client
+}
+
+// TestBanPlayer checks the requests against the argument order that
+// BanPlayer documents. That order has not been verified against a real
+// server, so these requests are no evidence for it.
+func TestBanPlayer(t *testing.T) {
+	const id = "76561198000000001"
+
+	tests := []struct {
+		name     string
+		reason   string
+		duration time.Duration
+		request  string
+		response string
+		wantErr  error
+	}{
+		{
+			name:     "accepted",
+			reason:   "griefing",
+			duration: time.Hour,
+			request:  "\x02\x20" + id + ",griefing,60",
+			response: "[2025.06.01-12.00.00] BanPlayer Banned " + id,
+		},
+		{
+			name:     "without response type",
+			reason:   "griefing",
+			duration: 90 * time.Second,
+			request:  "\x02\x20" + id + ",griefing,2",
+			response: "Banned " + id,
+		},
+		{
+			name:     "permanent",
+			reason:   "cheating",
+			request:  "\x02\x20" + id + ",cheating,0",
+			response: "BanPlayer Banned " + id,
+		},
+		{
+			name:     "echoed reason",
+			reason:   "error in chat",
+			duration: time.Minute,
+			request:  "\x02\x20" + id + ",error in chat,1",
+			response: "BanPlayer Banned " + id + " for error in chat",
+		},
+		{
+			name:     "echoed reason with comma",
+			reason:   "error, spam",
+			duration: time.Minute,
+			request:  "\x02\x20" + id + ",error\u201a spam,1",
+			response: "BanPlayer Banned " + id + " for error\u201a spam",
+		},
+		{
+			name:     "echoed reason with failure words",
+			reason:   "invalid name, fail",
+			duration: time.Minute,
+			request:  "\x02\x20" + id + ",invalid name\u201a fail,1",
+			response: "[2025.06.01-12.00.00] BanPlayer Banned " + id + " for invalid name\u201a fail",
+		},
+		{
+			name:     "rejected",
+			reason:   "griefing",
+			duration: time.Minute,
+			request:  "\x02\x20" + id + ",griefing,1",
+			response: "BanPlayer Player not found",
+			wantErr:  rcon.ErrCommandFailed,
+		},
+		{
+			name:     "rejected without response type",
+			reason:   "griefing",
+			duration: time.Minute,
+			request:  "\x02\x20" + id + ",griefing,1",
+			response: "[2025.06.01-12.00.00] Invalid duration",
+			wantErr:  rcon.ErrCommandFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := replay(t, tt.request, tt.response)
+			err := client.BanPlayer(id, tt.reason, tt.duration)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("BanPlayer: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBanPlayerLongestDuration(t *testing.T) {
+	_, client := connect(t)
+	if err := client.BanPlayer("76561198000000001", "griefing", math.MaxInt64); err != nil {
+		t.Fatalf("BanPlayer: %v", err)
+	}
+}
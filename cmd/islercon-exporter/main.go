@@ -0,0 +1,107 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Command islercon-exporter serves Prometheus metrics about a The Isle
+// Evrima server.
+//
+// Usage:
+//
+//	islercon-exporter [-listen 127.0.0.1:9877] -addr host:port
+//
+// The password is read from the -password flag or the ISLERCON_PASSWORD
+// environment variable, the address may also be given as ISLERCON_ADDR.
+// The metrics are served at /metrics.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/exporter"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9877", "address to serve the metrics on")
+	addr := flag.String("addr", os.Getenv("ISLERCON_ADDR"), "address of the server as host:port (env ISLERCON_ADDR)")
+	password := flag.String("password", "", "RCON password (env ISLERCON_PASSWORD)")
+	timeout := flag.Duration("timeout", exporter.DefaultTimeout, "time that a scrape may take")
+	flag.Parse()
+
+	if *password == "" {
+		*password = os.Getenv("ISLERCON_PASSWORD")
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := run(*listen, *addr, *password, *timeout, logger); err != nil {
+		fmt.Fprintf(os.Stderr, "islercon-exporter: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, addr, password string, timeout time.Duration, logger *slog.Logger) error {
+	if addr == "" {
+		return errors.New("no server address given; use -addr or ISLERCON_ADDR")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := rcon.ConnectContext(ctx, addr,
+		rcon.WithLogger(logger),
+		rcon.WithReconnect(rcon.ReconnectPolicy{}),
+	)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.AuthContext(ctx, password); err != nil {
+		return err
+	}
+
+	e := exporter.New(client)
+	e.Timeout = timeout
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", e)
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "islercon-exporter: metrics are at /metrics")
+	})
+
+	server := &http.Server{Addr: listen, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("serving metrics", "listen", listen, "server", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
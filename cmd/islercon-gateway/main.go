@@ -0,0 +1,148 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Command islercon-gateway serves a JSON API for a The Isle Evrima
+// server. See package httpgateway for the endpoints.
+//
+// Usage:
+//
+//	islercon-gateway [-listen 127.0.0.1:8080] -addr host:port -api-keys file
+//
+// The password is read from the -password flag or the ISLERCON_PASSWORD
+// environment variable, the address may also be given as ISLERCON_ADDR.
+// The API keys are read from the file given with -api-keys (one key per
+// line) or from ISLERCON_API_KEYS (separated by commas).
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/httpgateway"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8080", "address to serve the API on")
+	addr := flag.String("addr", os.Getenv("ISLERCON_ADDR"), "address of the server as host:port (env ISLERCON_ADDR)")
+	password := flag.String("password", "", "RCON password (env ISLERCON_PASSWORD)")
+	keysFile := flag.String("api-keys", "", "file with one API key per line (env ISLERCON_API_KEYS, comma-separated keys)")
+	timeout := flag.Duration("timeout", httpgateway.DefaultTimeout, "time that a request may take")
+	feedInterval := flag.Duration("feed-interval", 2*time.Second, "time between polls of the WebSocket feed at /feed, 0 disables the feed")
+	flag.Parse()
+
+	if *password == "" {
+		*password = os.Getenv("ISLERCON_PASSWORD")
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := run(*listen, *addr, *password, *keysFile, *timeout, *feedInterval, logger); err != nil {
+		fmt.Fprintf(os.Stderr, "islercon-gateway: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, addr, password, keysFile string, timeout, feedInterval time.Duration, logger *slog.Logger) error {
+	if addr == "" {
+		return errors.New("no server address given; use -addr or ISLERCON_ADDR")
+	}
+	keys, err := loadKeys(keysFile)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("no API keys given; use -api-keys or ISLERCON_API_KEYS")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := rcon.ConnectContext(ctx, addr,
+		rcon.WithLogger(logger),
+		rcon.WithReconnect(rcon.ReconnectPolicy{}),
+	)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.AuthContext(ctx, password); err != nil {
+		return err
+	}
+
+	gateway := httpgateway.New(client, keys...)
+	gateway.Timeout = timeout
+
+	if feedInterval > 0 {
+		feed := httpgateway.NewFeed(client, feedInterval)
+		gateway.HandleFeed(feed)
+		go feed.Run(ctx)
+	}
+
+	server := &http.Server{Addr: listen, Handler: gateway, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("serving API", "listen", listen, "server", addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// loadKeys reads the API keys from path or, if path is empty, from the
+// environment.
+func loadKeys(path string) ([]string, error) {
+	var keys []string
+	if path == "" {
+		for _, key := range strings.Split(os.Getenv("ISLERCON_API_KEYS"), ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key := strings.TrimSpace(scanner.Text())
+		if key != "" && !strings.HasPrefix(key, "#") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, scanner.Err()
+}
@@ -0,0 +1,409 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// A command is a subcommand of islercon. Commands either have a run
+// function or subcommands, never both.
+type command struct {
+	name    string
+	args    string
+	summary string
+	sub     []*command
+	run     func(ctx context.Context, client *rcon.Client, args []string) (*result, error)
+
+	// complete is the kind of the first argument, or of every argument if
+	// repeat is set. The shell uses it for tab completion.
+	complete argKind
+	repeat   bool
+}
+
+// argKind describes what an argument of a command is.
+type argKind int
+
+const (
+	argOther argKind = iota
+	argPlayer
+	argClass
+	argAIClass
+	argOnOff
+	argCommandByte
+)
+
+// errUsage is returned by run functions when the arguments are wrong.
+var errUsage = errors.New("invalid arguments")
+
+var commands = []*command{
+	{name: "players", summary: "List the players on the server", run: runPlayers},
+	{name: "playerdata", args: "[-lenient]", summary: "Show location, class and stats of every player", run: runPlayerData},
+	{name: "details", summary: "Show the server details", run: runDetails},
+	{name: "announce", args: "<message>", summary: "Send an announcement to all players", run: runAnnounce},
+	{name: "dm", args: "<player-id> <message>", summary: "Send a direct message to a player", run: runDirectMessage, complete: argPlayer},
+	{name: "kick", args: "<player-id> [reason]", summary: "Kick a player", run: runKick, complete: argPlayer},
+	{name: "ban", args: "<player-id> <duration|permanent> [reason]", summary: "Ban a player", run: runBan, complete: argPlayer},
+	{name: "whitelist", summary: "Manage the whitelist", sub: []*command{
+		{name: "add", args: "<player-id>...", summary: "Add players to the whitelist", run: runWhitelistAdd, complete: argPlayer, repeat: true},
+		{name: "remove", args: "<player-id>...", summary: "Remove players from the whitelist", run: runWhitelistRemove, complete: argPlayer, repeat: true},
+		{name: "set", args: "on|off", summary: "Turn the whitelist on or off", run: setter("whitelist", (*rcon.Client).SetWhitelistContext), complete: argOnOff},
+		{name: "toggle", summary: "Toggle the whitelist", run: toggler("whitelist", (*rcon.Client).ToggleWhitelistContext)},
+	}},
+	{name: "globalchat", summary: "Manage the global chat", sub: []*command{
+		{name: "set", args: "on|off", summary: "Turn the global chat on or off", run: setter("globalchat", (*rcon.Client).SetGlobalChatContext), complete: argOnOff},
+		{name: "toggle", summary: "Toggle the global chat", run: toggler("globalchat", (*rcon.Client).ToggleGlobalChatContext)},
+	}},
+	{name: "humans", summary: "Manage humans", sub: []*command{
+		{name: "set", args: "on|off", summary: "Turn humans on or off", run: setter("humans", (*rcon.Client).SetHumansContext), complete: argOnOff},
+		{name: "toggle", summary: "Toggle humans", run: toggler("humans", (*rcon.Client).ToggleHumansContext)},
+	}},
+	{name: "ai", summary: "Manage AI spawning", sub: []*command{
+		{name: "set", args: "on|off", summary: "Turn AI on or off", run: setter("ai", (*rcon.Client).SetAIContext), complete: argOnOff},
+		{name: "toggle", summary: "Toggle AI", run: toggler("ai", (*rcon.Client).ToggleAIContext)},
+		{name: "density", args: "<density>", summary: "Set the AI density", run: runAIDensity},
+		{name: "disable", args: "<ai-class>...", summary: "Set the AI classes that cannot spawn", run: runAIDisable, complete: argAIClass, repeat: true},
+	}},
+	{name: "playables", args: "<class>...", summary: "Set the playable classes", run: runPlayables, complete: argClass, repeat: true},
+	{name: "save", summary: "Save the map", run: runSave},
+	{name: "wipecorpses", summary: "Remove all corpses", run: runWipeCorpses},
+	{name: "exec", args: "<command> [param]...", summary: "Send a raw command without encoding", run: runExec, complete: argCommandByte},
+}
+
+// findCommand looks up the command named by the leading words of args
+// and returns it together with the remaining arguments. It returns the
+// deepest command found, which may be a group.
+func findCommand(list []*command, args []string) (*command, []string) {
+	var found *command
+	for len(args) > 0 {
+		var next *command
+		for _, cmd := range list {
+			if cmd.name == args[0] {
+				next = cmd
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		found = next
+		list = next.sub
+		args = args[1:]
+		if next.run != nil {
+			break
+		}
+	}
+	return found, args
+}
+
+// printCommands writes a summary of the commands in list to w.
+func printCommands(w io.Writer, prefix string, list []*command) {
+	for _, cmd := range list {
+		name := strings.TrimSpace(prefix + " " + cmd.name)
+		if cmd.sub != nil {
+			printCommands(w, name, cmd.sub)
+			continue
+		}
+		usage := strings.TrimSpace(name + " " + cmd.args)
+		fmt.Fprintf(w, "  %-45s %s\n", usage, cmd.summary)
+	}
+}
+
+func runPlayers(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) != 0 {
+		return nil, errUsage
+	}
+	players, err := client.GetPlayerListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return playerListResult(players), nil
+}
+
+func runPlayerData(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	lenient := false
+	switch {
+	case len(args) == 1 && args[0] == "-lenient":
+		lenient = true
+	case len(args) != 0:
+		return nil, errUsage
+	}
+
+	if !lenient {
+		players, err := client.GetPlayerDataContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return playerDataResult(players), nil
+	}
+
+	players, skipped, err := client.GetPlayerDataLenientContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := playerDataResult(players)
+	if len(skipped) > 0 {
+		res.Warnings = make([]string, len(skipped))
+		for i, err := range skipped {
+			res.Warnings[i] = err.Error()
+		}
+	}
+	return res, nil
+}
+
+func runDetails(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) != 0 {
+		return nil, errUsage
+	}
+	details, err := client.GetServerDetailsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return serverDetailsResult(details), nil
+}
+
+func runAnnounce(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	return nil, client.AnnounceContext(ctx, strings.Join(args, " "))
+}
+
+func runDirectMessage(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) < 2 {
+		return nil, errUsage
+	}
+	id, err := rcon.ParsePlayerID(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.SendDirectMessageContext(ctx, id, strings.Join(args[1:], " "))
+}
+
+func runKick(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) < 1 {
+		return nil, errUsage
+	}
+	id, err := rcon.ParsePlayerID(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.KickPlayerContext(ctx, id, strings.Join(args[1:], " "))
+}
+
+func runBan(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) < 2 {
+		return nil, errUsage
+	}
+	id, err := rcon.ParsePlayerID(args[0])
+	if err != nil {
+		return nil, err
+	}
+	duration, err := parseBanDuration(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.BanPlayerContext(ctx, id, strings.Join(args[2:], " "), duration)
+}
+
+// parseBanDuration accepts a Go duration such as "90m" or "24h", a plain
+// number of minutes, or "permanent".
+func parseBanDuration(s string) (time.Duration, error) {
+	if s == "permanent" {
+		return 0, nil
+	}
+	if minutes, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid ban duration %q", s)
+	}
+	return duration, nil
+}
+
+func runWhitelistAdd(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	ids, err := parsePlayerIDs(args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.AddWhitelistIDContext(ctx, ids...)
+}
+
+func runWhitelistRemove(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	ids, err := parsePlayerIDs(args)
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.RemoveWhitelistIDContext(ctx, ids...)
+}
+
+func parsePlayerIDs(args []string) ([]rcon.PlayerID, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	ids := make([]rcon.PlayerID, len(args))
+	for i, arg := range args {
+		id, err := rcon.ParsePlayerID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// setter returns a run function for one of the Set methods of the client.
+func setter(feature string, set func(*rcon.Client, context.Context, bool) (bool, error)) func(context.Context, *rcon.Client, []string) (*result, error) {
+	return func(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+		if len(args) != 1 {
+			return nil, errUsage
+		}
+		on, err := parseOnOff(args[0])
+		if err != nil {
+			return nil, err
+		}
+		changed, err := set(client, ctx, on)
+		if err != nil {
+			return nil, err
+		}
+		return stateResult(feature, on, changed), nil
+	}
+}
+
+// toggler returns a run function for one of the Toggle methods of the
+// client.
+func toggler(feature string, toggle func(*rcon.Client, context.Context) (bool, error)) func(context.Context, *rcon.Client, []string) (*result, error) {
+	return func(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+		if len(args) != 0 {
+			return nil, errUsage
+		}
+		on, err := toggle(client, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return stateResult(feature, on, true), nil
+	}
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off, got %q", s)
+}
+
+func runAIDensity(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) != 1 {
+		return nil, errUsage
+	}
+	density, err := strconv.ParseFloat(args[0], 32)
+	if err != nil || density < 0 {
+		return nil, fmt.Errorf("invalid AI density %q", args[0])
+	}
+	return nil, client.SetAIDensityContext(ctx, float32(density))
+}
+
+func runAIDisable(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	classes := make([]rcon.AIClass, len(args))
+	for i, arg := range args {
+		if !rcon.IsAIClass(arg) {
+			return nil, fmt.Errorf("unknown AI class %q", arg)
+		}
+		classes[i] = rcon.AIClass(arg)
+	}
+	return nil, client.DisableAIClassesContext(ctx, classes)
+}
+
+func runPlayables(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	classes := make([]rcon.DinoClass, len(args))
+	for i, arg := range args {
+		if !rcon.IsClass(arg) {
+			return nil, fmt.Errorf("unknown class %q", arg)
+		}
+		classes[i] = rcon.DinoClass(arg)
+	}
+	return nil, client.UpdatePlayablesContext(ctx, classes)
+}
+
+func runSave(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) != 0 {
+		return nil, errUsage
+	}
+	return nil, client.SaveContext(ctx)
+}
+
+func runWipeCorpses(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) != 0 {
+		return nil, errUsage
+	}
+	return nil, client.WipeCorpsesContext(ctx)
+}
+
+func runExec(ctx context.Context, client *rcon.Client, args []string) (*result, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	b, err := parseCommandByte(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.ExecCommandContext(ctx, b, args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	return rawResult(res), nil
+}
+
+// messageTypes are the commands in [rcon.MessageType].
+var messageTypes = []rcon.MessageType{
+	rcon.Announce, rcon.DirectMessage, rcon.GetServerDetails, rcon.WipeCorpses,
+	rcon.UpdatePlayables, rcon.BanPlayer, rcon.KickPlayer, rcon.GetPlayerList,
+	rcon.Save, rcon.GetPlayerData, rcon.ToggleWhitelist, rcon.AddWhitelistID,
+	rcon.RemoveWhitelistID, rcon.ToggleGlobalChat, rcon.ToggleHumans, rcon.ToggleAI,
+	rcon.DisableAIClasses, rcon.SetAIDensity,
+}
+
+// parseCommandByte accepts the name of a message type or a number such as
+// 0x40.
+func parseCommandByte(s string) (byte, error) {
+	for _, mt := range messageTypes {
+		if strings.EqualFold(rcon.CommandName(mt), s) {
+			return mt, nil
+		}
+	}
+	b, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid command byte %q", s)
+	}
+	return byte(b), nil
+}
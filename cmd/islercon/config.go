@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A profile holds the connection settings of one server.
+type profile struct {
+	Address  string
+	Password string
+}
+
+// defaultProfilePath returns the path of the profile file, which is
+// islercon/profiles.ini in the user's config directory.
+func defaultProfilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "islercon", "profiles.ini")
+}
+
+// loadProfile reads the profile with the given name from an INI file:
+//
+//	[default]
+//	address = 127.0.0.1:8888
+//	password = secret
+//
+// A missing file is not an error, unless a profile was explicitly asked
+// for.
+func loadProfile(path, name string, explicit bool) (profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return profile{}, nil
+		}
+		return profile{}, err
+	}
+	defer f.Close()
+
+	profiles, err := parseProfiles(f)
+	if err != nil {
+		return profile{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	p, ok := profiles[name]
+	if !ok && explicit {
+		return profile{}, fmt.Errorf("%s: no profile named %q", path, name)
+	}
+	return p, nil
+}
+
+func parseProfiles(r io.Reader) (map[string]profile, error) {
+	profiles := make(map[string]profile)
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		p := profiles[section]
+		switch key {
+		case "address", "addr":
+			p.Address = value
+		case "password":
+			p.Password = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", lineNumber, key)
+		}
+		profiles[section] = p
+	}
+
+	return profiles, scanner.Err()
+}
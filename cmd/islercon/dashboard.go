@@ -0,0 +1,539 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"golang.org/x/term"
+)
+
+// Escape sequences used by the dashboard.
+const (
+	enterAltScreen = "\x1b[?1049h"
+	leaveAltScreen = "\x1b[?1049l"
+	hideCursor     = "\x1b[?25l"
+	showCursor     = "\x1b[?25h"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+	reverseVideo   = "\x1b[7m"
+	boldText       = "\x1b[1m"
+	resetStyle     = "\x1b[0m"
+)
+
+// sortColumns are the columns that the player table can be sorted by.
+var sortColumns = []struct {
+	name    string
+	compare func(a, b rcon.Player) int
+}{
+	{"name", func(a, b rcon.Player) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) }},
+	{"class", func(a, b rcon.Player) int { return cmp.Compare(a.DinoClass, b.DinoClass) }},
+	{"growth", func(a, b rcon.Player) int { return cmp.Compare(a.Growth, b.Growth) }},
+	{"health", func(a, b rcon.Player) int { return cmp.Compare(a.Health, b.Health) }},
+}
+
+// inputMode is what the line at the bottom of the dashboard is used for.
+type inputMode int
+
+const (
+	modeBrowse inputMode = iota
+	modeMessage
+	modeKick
+)
+
+// A dashboard shows the state of the server on the whole terminal and
+// refreshes it periodically.
+type dashboard struct {
+	client rcon.Commander
+	out    io.Writer
+	fd     int
+
+	// The result of the latest refresh.
+	details *rcon.ServerDetails
+	players []rcon.Player
+	skipped int
+	updated time.Time
+	err     error
+
+	refreshing bool
+	status     string
+
+	sortBy   int
+	reverse  bool
+	cursor   int
+	offset   int
+	selected rcon.PlayerID
+
+	mode   inputMode
+	input  []rune
+	target rcon.Player
+}
+
+// refreshResult is the outcome of one refresh.
+type refreshResult struct {
+	details *rcon.ServerDetails
+	players []rcon.Player
+	skipped int
+	err     error
+}
+
+func runDashboard(ctx context.Context, client rcon.Commander, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	interval := flags.Duration("interval", 5*time.Second, "time between refreshes")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return errors.New("the refresh interval must be positive")
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("the dashboard needs a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Fprint(stdout, enterAltScreen+hideCursor)
+	defer fmt.Fprint(stdout, showCursor+leaveAltScreen)
+
+	d := &dashboard{client: client, out: stdout, fd: fd, sortBy: 2, reverse: true}
+
+	keys := make(chan []key)
+	go readKeys(os.Stdin, keys)
+
+	refreshes := make(chan refreshResult, 1)
+	actions := make(chan string, 1)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	d.refresh(ctx, refreshes)
+	for {
+		d.render()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case pressed, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range pressed {
+				if !d.handleKey(ctx, k, actions) {
+					return nil
+				}
+			}
+		case <-ticker.C:
+			d.refresh(ctx, refreshes)
+		case res := <-refreshes:
+			d.refreshing = false
+			d.updated = time.Now()
+			d.err = res.err
+			if res.err == nil {
+				d.details = res.details
+				d.players = res.players
+				d.skipped = res.skipped
+				d.sortPlayers()
+			}
+		case status := <-actions:
+			d.status = status
+			d.refresh(ctx, refreshes)
+		}
+	}
+}
+
+// refresh fetches the server details and player data in the background,
+// unless a refresh is already running.
+func (d *dashboard) refresh(ctx context.Context, results chan<- refreshResult) {
+	if d.refreshing {
+		return
+	}
+	d.refreshing = true
+
+	go func() {
+		var res refreshResult
+		res.details, res.err = d.client.GetServerDetailsContext(ctx)
+		if res.err == nil {
+			var skipped []*rcon.PlayerDataError
+			res.players, skipped, res.err = d.client.GetPlayerDataLenientContext(ctx)
+			res.skipped = len(skipped)
+		}
+		results <- res
+	}()
+}
+
+// handleKey reacts to a key press. It returns false if the dashboard
+// should exit.
+func (d *dashboard) handleKey(ctx context.Context, k key, actions chan<- string) bool {
+	if d.mode != modeBrowse {
+		d.handleInputKey(ctx, k, actions)
+		return true
+	}
+
+	d.status = ""
+	switch k.special {
+	case keyInterrupt:
+		return false
+	case keyUp:
+		d.moveCursor(-1)
+	case keyDown:
+		d.moveCursor(1)
+	case keyPageUp:
+		d.moveCursor(-d.tableHeight())
+	case keyPageDown:
+		d.moveCursor(d.tableHeight())
+	case keyHome:
+		d.moveCursor(-len(d.players))
+	case keyEnd:
+		d.moveCursor(len(d.players))
+	}
+
+	switch k.rune {
+	case 'q':
+		return false
+	case 's':
+		d.sortBy = (d.sortBy + 1) % len(sortColumns)
+		d.sortPlayers()
+	case 'r':
+		d.reverse = !d.reverse
+		d.sortPlayers()
+	case 'm', 'k':
+		player, ok := d.selectedPlayer()
+		if !ok {
+			break
+		}
+		d.target = player
+		d.input = d.input[:0]
+		d.mode = modeMessage
+		if k.rune == 'k' {
+			d.mode = modeKick
+		}
+		fmt.Fprint(d.out, showCursor)
+	}
+	return true
+}
+
+// handleInputKey edits the message or kick reason and runs the action
+// on enter.
+func (d *dashboard) handleInputKey(ctx context.Context, k key, actions chan<- string) {
+	switch k.special {
+	case keyNone:
+		d.input = append(d.input, k.rune)
+		return
+	case keyBackspace:
+		if len(d.input) > 0 {
+			d.input = d.input[:len(d.input)-1]
+		}
+		return
+	case keyEnter:
+		d.runAction(ctx, actions)
+	case keyEscape, keyInterrupt:
+	default:
+		return
+	}
+
+	d.mode = modeBrowse
+	fmt.Fprint(d.out, hideCursor)
+}
+
+// runAction sends the direct message or kicks the target player in the
+// background. The outcome is sent to actions.
+func (d *dashboard) runAction(ctx context.Context, actions chan<- string) {
+	mode, target, text := d.mode, d.target, string(d.input)
+	if mode == modeMessage && strings.TrimSpace(text) == "" {
+		return
+	}
+
+	d.status = "Sending..."
+	go func() {
+		var err error
+		var done string
+		switch mode {
+		case modeMessage:
+			err = d.client.SendDirectMessageContext(ctx, target.ID, text)
+			done = "Sent message to " + target.Name
+		case modeKick:
+			err = d.client.KickPlayerContext(ctx, target.ID, text)
+			done = "Kicked " + target.Name
+		}
+		if err != nil {
+			done = "Error: " + err.Error()
+		}
+		actions <- done
+	}()
+}
+
+func (d *dashboard) sortPlayers() {
+	compare := sortColumns[d.sortBy].compare
+	slices.SortStableFunc(d.players, func(a, b rcon.Player) int {
+		c := compare(a, b)
+		if d.reverse {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		return c
+	})
+
+	// Keep the same player selected.
+	if i := slices.IndexFunc(d.players, func(p rcon.Player) bool { return p.ID == d.selected }); i >= 0 {
+		d.cursor = i
+	}
+	d.moveCursor(0)
+}
+
+func (d *dashboard) moveCursor(delta int) {
+	d.cursor = max(0, min(d.cursor+delta, len(d.players)-1))
+	if player, ok := d.selectedPlayer(); ok {
+		d.selected = player.ID
+	}
+}
+
+func (d *dashboard) selectedPlayer() (rcon.Player, bool) {
+	if d.cursor < 0 || d.cursor >= len(d.players) {
+		return rcon.Player{}, false
+	}
+	return d.players[d.cursor], true
+}
+
+func (d *dashboard) size() (width, height int) {
+	width, height, err := term.GetSize(d.fd)
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// tableHeight is the number of player rows that fit on the screen. The
+// rest is used by the header (five lines) and the footer (two lines).
+func (d *dashboard) tableHeight() int {
+	_, height := d.size()
+	return max(1, height-7)
+}
+
+func (d *dashboard) render() {
+	width, _ := d.size()
+	rows := d.tableHeight()
+
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	}
+	if d.cursor >= d.offset+rows {
+		d.offset = d.cursor - rows + 1
+	}
+
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	line := func(s, style string) {
+		b.WriteString(style)
+		b.WriteString(truncate(sanitize(s), width))
+		if style != "" {
+			b.WriteString(resetStyle)
+		}
+		b.WriteString(clearLine + "\r\n")
+	}
+
+	line(d.titleLine(), boldText)
+	line(d.toggleLine(), "")
+	line(d.classLine(), "")
+	line("", "")
+	line(d.tableHeader(), boldText)
+
+	for i := range rows {
+		index := d.offset + i
+		if index >= len(d.players) {
+			line("", "")
+			continue
+		}
+		style := ""
+		if index == d.cursor {
+			style = reverseVideo
+		}
+		line(formatPlayerRow(d.players[index]), style)
+	}
+
+	line(d.statusLine(), "")
+	b.WriteString(truncate(sanitize(d.footerLine()), width) + clearLine + clearBelow)
+
+	// The screen is only drawn once it is complete, so it does not flicker.
+	io.WriteString(d.out, b.String())
+}
+
+func (d *dashboard) titleLine() string {
+	if d.details == nil {
+		return "islercon dashboard: connecting..."
+	}
+	title := fmt.Sprintf("%s | %s | %d/%d players", d.details.Name, d.details.Map, d.details.CurrentPlayers, d.details.MaxPlayers)
+	if !d.details.Timestamp.IsZero() {
+		title += " | server time " + d.details.Timestamp.Format(time.TimeOnly)
+	}
+	return title + " | updated " + d.updated.Format(time.TimeOnly)
+}
+
+func (d *dashboard) toggleLine() string {
+	if d.details == nil {
+		return ""
+	}
+	toggles := []struct {
+		name string
+		on   bool
+	}{
+		{"Whitelist", d.details.Whitelist},
+		{"Global chat", d.details.EnableGlobalChat},
+		{"Humans", d.details.EnableHumans},
+		{"AI", d.details.SpawnAI},
+		{"Mutations", d.details.EnableMutations},
+		{"Queue", d.details.QueueEnabled},
+		{"Password", d.details.HasPassword},
+	}
+	parts := make([]string, len(toggles))
+	for i, toggle := range toggles {
+		parts[i] = toggle.name + ": " + strings.ToUpper(formatOnOff(toggle.on))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// classLine shows how many players play each class, most played first.
+func (d *dashboard) classLine() string {
+	counts := make(map[rcon.DinoClass]int)
+	for _, player := range d.players {
+		counts[player.DinoClass]++
+	}
+	classes := make([]rcon.DinoClass, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	slices.SortFunc(classes, func(a, b rcon.DinoClass) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		name := class.Name()
+		if name == "" {
+			name = "(none)"
+		}
+		parts[i] = fmt.Sprintf("%s %d", name, counts[class])
+	}
+	return strings.Join(parts, "  ")
+}
+
+const playerRowFormat = "%-20s %-17s %-18s %6s %6s %7s %6s %6s  %s"
+
+func (d *dashboard) tableHeader() string {
+	columns := []string{"NAME", "ID", "CLASS", "GROWTH", "HEALTH"}
+	for i, column := range sortColumns {
+		if i == d.sortBy {
+			arrow := "^"
+			if d.reverse {
+				arrow = "v"
+			}
+			switch column.name {
+			case "name":
+				columns[0] += arrow
+			case "class":
+				columns[2] += arrow
+			case "growth":
+				columns[3] += arrow
+			case "health":
+				columns[4] += arrow
+			}
+		}
+	}
+	return fmt.Sprintf(playerRowFormat, columns[0], columns[1], columns[2], columns[3], columns[4], "STAMINA", "HUNGER", "THIRST", "LOCATION")
+}
+
+func formatPlayerRow(player rcon.Player) string {
+	return fmt.Sprintf(playerRowFormat,
+		truncate(sanitize(player.Name), 20),
+		player.ID,
+		truncate(player.DinoClass.Name(), 18),
+		fmt.Sprintf("%d%%", player.Growth),
+		fmt.Sprintf("%d%%", player.Health),
+		fmt.Sprintf("%d%%", player.Stamina),
+		fmt.Sprintf("%d%%", player.Hunger),
+		fmt.Sprintf("%d%%", player.Thirst),
+		fmt.Sprintf("%.0f, %.0f, %.0f", player.Location.X, player.Location.Y, player.Location.Z),
+	)
+}
+
+func (d *dashboard) statusLine() string {
+	switch {
+	case d.err != nil:
+		return "Error: " + d.err.Error()
+	case d.status != "":
+		return d.status
+	case d.skipped > 0:
+		return fmt.Sprintf("%d players could not be parsed", d.skipped)
+	}
+	return ""
+}
+
+func (d *dashboard) footerLine() string {
+	switch d.mode {
+	case modeMessage:
+		return "Message to " + d.target.Name + " (enter to send, esc to cancel): " + string(d.input)
+	case modeKick:
+		return "Kick " + d.target.Name + ", reason (enter to kick, esc to cancel): " + string(d.input)
+	}
+	return "up/down select  s sort by " + sortColumns[(d.sortBy+1)%len(sortColumns)].name + "  r reverse  m message  k kick  q quit"
+}
+
+// sanitize replaces control and formatting characters in s with '?'. Player
+// and server names are chosen by players and admins, and an escape sequence
+// in one of them would otherwise be interpreted by the terminal.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// truncate shortens s to at most width runes. It counts runes rather than
+// bytes so that names with non-ASCII characters line up with the padding
+// of fmt, which counts runes as well.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width])
+}
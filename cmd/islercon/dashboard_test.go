@@ -0,0 +1,144 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Alice", "Alice"},
+		{"non-ASCII", "Ålice 🦖", "Ålice 🦖"},
+		{"escape sequence", "\x1b[2JAlice", "?[2JAlice"},
+		{"title sequence", "\x1b]0;pwned\aAlice", "?]0;pwned?Alice"},
+		{"C1 control", "\u009b2JAlice", "?2JAlice"},
+		{"newlines", "Al\r\nice", "Al??ice"},
+		{"tab", "Al\tice", "Al?ice"},
+		{"bidi override", "\u202eecilA", "?ecilA"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitize(tt.in); got != tt.want {
+				t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"Alice", 10, "Alice"},
+		{"Alice", 5, "Alice"},
+		{"Alice", 3, "Ali"},
+		{"Ålice", 2, "Ål"},
+		{"🦖🦖🦖", 2, "🦖🦖"},
+		{"Alice", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.width); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPlayerRow(t *testing.T) {
+	player := rcon.Player{
+		Name:      "Alice",
+		ID:        "76561197960287930",
+		DinoClass: rcon.Troodon,
+		Growth:    75,
+		Health:    100,
+		Stamina:   50,
+		Hunger:    25,
+		Thirst:    0,
+		Location:  rcon.Location{X: 1.4, Y: -2.6, Z: 3},
+	}
+	want := "Alice                76561197960287930 Troodon               75%   100%     50%    25%     0%  1, -3, 3"
+	if got := formatPlayerRow(player); got != want {
+		t.Errorf("formatPlayerRow() =\n%q, want\n%q", got, want)
+	}
+
+	// The columns after the name must stay in place whatever the name is.
+	column := strings.Index(want, "76561197960287930")
+	for _, name := range []string{
+		"",
+		"A very long name that does not fit",
+		"Ålice the 🦖 enjoyer of the Isle",
+		"\x1b[2J\x1b[HAlice",
+		"Al\r\nice",
+	} {
+		player.Name = name
+		row := formatPlayerRow(player)
+		if strings.ContainsFunc(row, unicode.IsControl) {
+			t.Errorf("row for %q contains control characters: %q", name, row)
+		}
+		prefix, _, ok := strings.Cut(row, string(player.ID))
+		if !ok || utf8.RuneCountInString(prefix) != column {
+			t.Errorf("row for %q has the ID at rune %d, want %d: %q", name, utf8.RuneCountInString(prefix), column, row)
+		}
+	}
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	var out bytes.Buffer
+	d := &dashboard{
+		out: &out,
+		fd:  -1,
+		details: &rcon.ServerDetails{
+			Name: "My \x1b]0;pwned\a server",
+			Map:  "Gateway\x1b[31m",
+		},
+		players: []rcon.Player{{Name: "\x1b[2JAlice", ID: "76561197960287930"}},
+		status:  "Kicked \x1b[2JAlice",
+		mode:    modeKick,
+		target:  rcon.Player{Name: "\x1b[2JAlice", ID: "76561197960287930"},
+		input:   []rune("bye\x1b[H"),
+	}
+	d.render()
+
+	// Remove the sequences the dashboard writes itself. Anything left
+	// must have come from the server.
+	screen := out.String()
+	for _, sequence := range []string{cursorHome, clearLine, clearBelow, boldText, reverseVideo, resetStyle, "\r\n"} {
+		screen = strings.ReplaceAll(screen, sequence, "")
+	}
+	if strings.ContainsFunc(screen, unicode.IsControl) {
+		t.Errorf("screen contains control characters from the server: %q", screen)
+	}
+	for _, want := range []string{"My ?]0;pwned? server", "Gateway?[31m", "?[2JAlice", "Kicked ?[2JAlice", "Kick ?[2JAlice", "bye?[H"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen does not contain %q: %q", want, screen)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// maxHistory is the number of lines that the shell keeps in its history.
+const maxHistory = 500
+
+// history is the command history of the shell. It implements
+// [term.History] and appends every line to a file, so that the history
+// survives between sessions. The file is cut down to the last maxHistory
+// lines when the history is opened and closed.
+type history struct {
+	lines []string // Oldest first
+	path  string
+	file  *os.File
+	// fileLines is the number of lines in the file.
+	fileLines int
+}
+
+// openHistory loads the history from islercon/history in the user's
+// config directory.
+func openHistory() (*history, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "islercon", "history")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	h := &history{path: path}
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			h.append(scanner.Text())
+			h.fileLines++
+		}
+		f.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if h.fileLines > maxHistory {
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	h.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// rewrite replaces the file with the lines that are kept in memory.
+func (h *history) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), "history-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range h.lines {
+		w.WriteString(line + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return err
+	}
+	h.fileLines = len(h.lines)
+	return nil
+}
+
+func (h *history) Add(entry string) {
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1] == entry {
+		return
+	}
+	h.append(entry)
+	if _, err := h.file.WriteString(entry + "\n"); err == nil {
+		h.fileLines++
+	}
+}
+
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+func (h *history) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+func (h *history) Close() error {
+	if err := h.file.Close(); err != nil {
+		return err
+	}
+	if h.fileLines > maxHistory {
+		return h.rewrite()
+	}
+	return nil
+}
+
+func (h *history) append(entry string) {
+	if len(h.lines) == maxHistory {
+		h.lines = append(h.lines[:0], h.lines[1:]...)
+	}
+	h.lines = append(h.lines, entry)
+}
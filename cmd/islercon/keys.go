@@ -0,0 +1,132 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// A key is a key press read from a terminal in raw mode. Either rune or
+// special is set.
+type key struct {
+	rune    rune
+	special specialKey
+}
+
+type specialKey int
+
+const (
+	keyNone specialKey = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyInterrupt
+	keyUnknown
+)
+
+// escapeSequences are the escape sequences that VT100-like terminals
+// send for special keys.
+var escapeSequences = []struct {
+	seq string
+	key specialKey
+}{
+	{"\x1b[A", keyUp},
+	{"\x1b[B", keyDown},
+	{"\x1bOA", keyUp},
+	{"\x1bOB", keyDown},
+	{"\x1b[5~", keyPageUp},
+	{"\x1b[6~", keyPageDown},
+	{"\x1b[H", keyHome},
+	{"\x1b[F", keyEnd},
+	{"\x1b[1~", keyHome},
+	{"\x1b[4~", keyEnd},
+}
+
+// readKeys reads key presses from r and sends them to keys until r
+// fails. It then closes keys.
+func readKeys(r io.Reader, keys chan<- []key) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			keys <- decodeKeys(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// decodeKeys splits the bytes of one read into key presses. A lone
+// escape byte is the escape key; unknown escape sequences are skipped.
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			k, n := decodeEscape(b)
+			keys = append(keys, key{special: k})
+			b = b[n:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{special: keyEnter})
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{special: keyBackspace})
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, key{special: keyInterrupt})
+			b = b[1:]
+		case c < 0x20:
+			keys = append(keys, key{special: keyUnknown})
+			b = b[1:]
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, key{rune: r})
+			b = b[n:]
+		}
+	}
+	return keys
+}
+
+func decodeEscape(b []byte) (specialKey, int) {
+	for _, es := range escapeSequences {
+		if bytes.HasPrefix(b, []byte(es.seq)) {
+			return es.key, len(es.seq)
+		}
+	}
+	if len(b) == 1 || (b[1] != '[' && b[1] != 'O') {
+		return keyEscape, 1
+	}
+
+	// Skip the unknown sequence up to its final byte.
+	for i := 2; i < len(b); i++ {
+		if b[i] >= 0x40 && b[i] <= 0x7e {
+			return keyUnknown, i + 1
+		}
+	}
+	return keyUnknown, len(b)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Command islercon runs RCON commands against a The Isle Evrima server.
+//
+// Usage:
+//
+//	islercon [flags] <command> [arguments]
+//
+// The address and password of the server are taken from the -addr and
+// -password flags, the ISLERCON_ADDR and ISLERCON_PASSWORD environment
+// variables or a profile in the profile file, in that order. Run
+// islercon -h for the list of commands, or islercon shell for an
+// interactive shell.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// options are the global flags of islercon.
+type options struct {
+	addr     string
+	password string
+	profile  string
+	config   string
+	format   string
+	timeout  time.Duration
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	flags := flag.NewFlagSet("islercon", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.addr, "addr", "", "address of the server as host:port (env ISLERCON_ADDR)")
+	flags.StringVar(&opts.password, "password", "", "RCON password (env ISLERCON_PASSWORD)")
+	flags.StringVar(&opts.profile, "profile", "", "name of the profile to use (env ISLERCON_PROFILE, default \"default\")")
+	flags.StringVar(&opts.config, "config", "", "path of the profile file (env ISLERCON_CONFIG, default "+defaultProfilePath()+")")
+	flags.StringVar(&opts.format, "format", "table", "output format: table, json or csv")
+	flags.DurationVar(&opts.timeout, "timeout", rcon.DefaultTimeout, "timeout of each RCON command")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: islercon [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "\nCommands:")
+		printCommands(stderr, "", commands)
+		fmt.Fprintf(stderr, "  %-45s %s\n", "shell", "Start an interactive shell")
+		fmt.Fprintf(stderr, "  %-45s %s\n", "dashboard [-interval 5s]", "Show the server state on the whole terminal")
+		fmt.Fprintln(stderr, "\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if !slices.Contains(formats, opts.format) {
+		fmt.Fprintf(stderr, "islercon: unknown output format %q\n", opts.format)
+		return 2
+	}
+
+	interactive := flags.Arg(0) == "shell" || flags.Arg(0) == "dashboard"
+	cmd, rest := findCommand(commands, flags.Args())
+	if cmd == nil && !interactive {
+		flags.Usage()
+		return 2
+	}
+	if cmd != nil && cmd.run == nil {
+		fmt.Fprintf(stderr, "Usage of %s:\n", cmd.name)
+		printCommands(stderr, cmd.name, cmd.sub)
+		return 2
+	}
+
+	if err := resolveConnection(&opts); err != nil {
+		fmt.Fprintf(stderr, "islercon: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := connect(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "islercon: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	if interactive {
+		if flags.Arg(0) == "shell" {
+			err = runShell(ctx, client, opts.format, stdout)
+		} else {
+			err = runDashboard(ctx, client, flags.Args()[1:], stdout, stderr)
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "islercon: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	res, err := cmd.run(ctx, client, rest)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "Usage: islercon %s\n", commandUsage(flags.Args(), rest, cmd))
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "islercon: %v\n", err)
+		return 1
+	}
+
+	if res != nil {
+		for _, warning := range res.Warnings {
+			fmt.Fprintf(stderr, "islercon: warning: %s\n", warning)
+		}
+	}
+	if err := writeResult(stdout, opts.format, res); err != nil {
+		fmt.Fprintf(stderr, "islercon: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// commandUsage returns the usage line of cmd, which was found in args
+// with rest remaining.
+func commandUsage(args, rest []string, cmd *command) string {
+	name := strings.Join(args[:len(args)-len(rest)], " ")
+	return strings.TrimSpace(name + " " + cmd.args)
+}
+
+// resolveConnection fills in the address and password from the
+// environment and the profile file where no flag was given.
+func resolveConnection(opts *options) error {
+	if opts.addr == "" {
+		opts.addr = os.Getenv("ISLERCON_ADDR")
+	}
+	if opts.password == "" {
+		opts.password = os.Getenv("ISLERCON_PASSWORD")
+	}
+	if opts.addr != "" && opts.password != "" {
+		return nil
+	}
+
+	if opts.profile == "" {
+		opts.profile = os.Getenv("ISLERCON_PROFILE")
+	}
+	if opts.config == "" {
+		opts.config = os.Getenv("ISLERCON_CONFIG")
+	}
+	explicit := opts.profile != "" || opts.config != ""
+	if opts.profile == "" {
+		opts.profile = "default"
+	}
+	if opts.config == "" {
+		opts.config = defaultProfilePath()
+	}
+
+	p, err := loadProfile(opts.config, opts.profile, explicit)
+	if err != nil {
+		return err
+	}
+	if opts.addr == "" {
+		opts.addr = p.Address
+	}
+	if opts.password == "" {
+		opts.password = p.Password
+	}
+
+	if opts.addr == "" {
+		return errors.New("no server address given; use -addr, ISLERCON_ADDR or a profile")
+	}
+	return nil
+}
+
+// connect connects to the server and authenticates.
+func connect(ctx context.Context, opts options) (*rcon.Client, error) {
+	client, err := rcon.ConnectContext(ctx, opts.addr,
+		rcon.WithDialTimeout(opts.timeout),
+		rcon.WithReadTimeout(opts.timeout),
+		rcon.WithWriteTimeout(opts.timeout),
+		// Keeps the shell and the dashboard usable after a command timed
+		// out.
+		rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 3}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.AuthContext(ctx, opts.password); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
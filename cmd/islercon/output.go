@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// formats are the supported output formats.
+var formats = []string{"table", "json", "csv"}
+
+// A result is the output of a command. Value is used for JSON output,
+// Header and Rows for table and CSV output. Warnings are printed to
+// stderr in every format.
+type result struct {
+	Value    any
+	Header   []string
+	Rows     [][]string
+	Warnings []string
+}
+
+func writeResult(w io.Writer, format string, res *result) error {
+	if res == nil {
+		return nil
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res.Value)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(res.Header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(res.Rows); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(res.Header, "\t"))
+		for _, row := range res.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func playerListResult(players []rcon.Player) *result {
+	rows := make([][]string, len(players))
+	for i, player := range players {
+		rows[i] = []string{string(player.ID), player.Name}
+	}
+	return &result{Value: players, Header: []string{"ID", "NAME"}, Rows: rows}
+}
+
+func playerDataResult(players []rcon.Player) *result {
+	rows := make([][]string, len(players))
+	for i, player := range players {
+		rows[i] = []string{
+			string(player.ID),
+			player.Name,
+			player.DinoClass.Name(),
+			strconv.Itoa(int(player.Growth)),
+			strconv.Itoa(int(player.Health)),
+			strconv.Itoa(int(player.Stamina)),
+			strconv.Itoa(int(player.Hunger)),
+			strconv.Itoa(int(player.Thirst)),
+			strconv.FormatFloat(player.Location.X, 'f', 3, 64),
+			strconv.FormatFloat(player.Location.Y, 'f', 3, 64),
+			strconv.FormatFloat(player.Location.Z, 'f', 3, 64),
+		}
+	}
+	header := []string{"ID", "NAME", "CLASS", "GROWTH", "HEALTH", "STAMINA", "HUNGER", "THIRST", "X", "Y", "Z"}
+	return &result{Value: players, Header: header, Rows: rows}
+}
+
+func serverDetailsResult(details *rcon.ServerDetails) *result {
+	rows := [][]string{
+		{"Name", details.Name},
+		{"Password", details.Password},
+		{"Map", details.Map},
+		{"MaxPlayers", strconv.Itoa(details.MaxPlayers)},
+		{"CurrentPlayers", strconv.Itoa(details.CurrentPlayers)},
+		{"EnableMutations", strconv.FormatBool(details.EnableMutations)},
+		{"EnableHumans", strconv.FormatBool(details.EnableHumans)},
+		{"HasPassword", strconv.FormatBool(details.HasPassword)},
+		{"QueueEnabled", strconv.FormatBool(details.QueueEnabled)},
+		{"Whitelist", strconv.FormatBool(details.Whitelist)},
+		{"SpawnAI", strconv.FormatBool(details.SpawnAI)},
+		{"AllowRecordingGameplay", strconv.FormatBool(details.AllowRecordingGameplay)},
+		{"UseRegionSpawning", strconv.FormatBool(details.UseRegionSpawning)},
+		{"UseRegionSpawnCooldown", strconv.FormatBool(details.UseRegionSpawnCooldown)},
+		{"RegionSpawnCooldownTimeSeconds", strconv.Itoa(details.RegionSpawnCooldownTimeSeconds)},
+		{"DayLengthMinutes", strconv.Itoa(details.DayLengthMinutes)},
+		{"NightLengthMinutes", strconv.Itoa(details.NightLengthMinutes)},
+		{"EnableGlobalChat", strconv.FormatBool(details.EnableGlobalChat)},
+	}
+	for _, key := range slices.Sorted(maps.Keys(details.Extra)) {
+		rows = append(rows, []string{key, details.Extra[key]})
+	}
+	return &result{Value: details, Header: []string{"KEY", "VALUE"}, Rows: rows}
+}
+
+// stateResult is the output of commands that turn a feature on or off.
+func stateResult(feature string, on, changed bool) *result {
+	value := struct {
+		Feature string `json:"feature"`
+		On      bool   `json:"on"`
+		Changed bool   `json:"changed"`
+	}{feature, on, changed}
+	row := []string{feature, formatOnOff(on), strconv.FormatBool(changed)}
+	return &result{Value: value, Header: []string{"FEATURE", "STATE", "CHANGED"}, Rows: [][]string{row}}
+}
+
+// rawResult is the output of commands that return the response as is.
+func rawResult(response string) *result {
+	value := struct {
+		Response string `json:"response"`
+	}{response}
+	return &result{Value: value, Header: []string{"RESPONSE"}, Rows: [][]string{{response}}}
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,438 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"golang.org/x/term"
+)
+
+const (
+	shellPrompt = "islercon> "
+	rawPrompt   = "raw> "
+)
+
+// A shell runs commands read line by line on one connection.
+type shell struct {
+	client *rcon.Client
+	format string
+	out    io.Writer
+
+	// raw is set while every line is sent through ExecCommand as is.
+	raw bool
+
+	// players is the result of the latest command that listed players. It
+	// is used for tab completion.
+	players []rcon.Player
+
+	// setPrompt changes the prompt. It is nil if stdin is not a terminal.
+	setPrompt func(string)
+}
+
+// runShell reads commands from stdin until the end of the input. If stdin
+// is a terminal, it offers line editing, history and tab completion.
+func runShell(ctx context.Context, client *rcon.Client, format string, stdout io.Writer) error {
+	sh := &shell{client: client, format: format, out: stdout}
+
+	sh.players, _ = client.GetPlayerListContext(ctx)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if !sh.exec(ctx, scanner.Text()) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, stdout}, shellPrompt)
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return sh.complete(terminal, line, pos)
+	}
+	if history, err := openHistory(); err == nil {
+		defer history.Close()
+		terminal.History = history
+	}
+	if width, height, err := term.GetSize(fd); err == nil {
+		terminal.SetSize(width, height)
+	}
+
+	sh.out = terminal
+	sh.setPrompt = terminal.SetPrompt
+
+	fmt.Fprintln(terminal, `Type "help" for a list of commands.`)
+	for ctx.Err() == nil {
+		line, err := terminal.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+			return err
+		}
+		if !sh.exec(ctx, line) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// exec runs one line of input. It returns false if the shell should exit.
+func (sh *shell) exec(ctx context.Context, line string) bool {
+	if sh.raw {
+		switch strings.TrimSpace(line) {
+		case "":
+		case "exit", "quit":
+			sh.setRaw(false)
+		default:
+			sh.execRaw(ctx, line)
+		}
+		return true
+	}
+
+	args, err := splitWords(line)
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+		return true
+	}
+	if len(args) == 0 {
+		return true
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return false
+	case "help":
+		sh.help()
+		return true
+	case "format":
+		if len(args) != 2 || !slices.Contains(formats, args[1]) {
+			fmt.Fprintln(sh.out, "usage: format table|json|csv")
+			return true
+		}
+		sh.format = args[1]
+		return true
+	case "raw":
+		if len(args) == 1 {
+			sh.setRaw(true)
+			return true
+		}
+		sh.execRaw(ctx, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "raw")))
+		return true
+	}
+
+	cmd, rest := findCommand(commands, args)
+	if cmd == nil {
+		fmt.Fprintf(sh.out, "unknown command %q\n", args[0])
+		return true
+	}
+	if cmd.run == nil {
+		printCommands(sh.out, strings.Join(args[:len(args)-len(rest)], " "), cmd.sub)
+		return true
+	}
+
+	res, err := cmd.run(ctx, sh.client, rest)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(sh.out, "usage: %s\n", commandUsage(args, rest, cmd))
+		return true
+	}
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+		return true
+	}
+	if res == nil {
+		return true
+	}
+
+	if players, ok := res.Value.([]rcon.Player); ok {
+		sh.players = players
+	}
+	for _, warning := range res.Warnings {
+		fmt.Fprintf(sh.out, "warning: %s\n", warning)
+	}
+	if err := writeResult(sh.out, sh.format, res); err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+	}
+	return true
+}
+
+// execRaw sends a line of the form "<command> [payload]" through
+// ExecCommand. The payload may contain Go escape sequences such as \x00,
+// and is sent without any encoding. The response is printed quoted, so
+// that unprintable bytes are visible.
+func (sh *shell) execRaw(ctx context.Context, line string) {
+	name, payload, _ := strings.Cut(line, " ")
+	b, err := parseCommandByte(name)
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+		return
+	}
+	payload, err = unescape(payload)
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: invalid payload: %v\n", err)
+		return
+	}
+
+	var params []string
+	if payload != "" {
+		params = []string{payload}
+	}
+	res, err := sh.client.ExecCommandContext(ctx, b, params...)
+	if err != nil {
+		fmt.Fprintf(sh.out, "error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(sh.out, strconv.Quote(res))
+}
+
+func (sh *shell) setRaw(raw bool) {
+	sh.raw = raw
+	if sh.setPrompt == nil {
+		return
+	}
+	if raw {
+		sh.setPrompt(rawPrompt)
+	} else {
+		sh.setPrompt(shellPrompt)
+	}
+}
+
+func (sh *shell) help() {
+	fmt.Fprintln(sh.out, "Commands:")
+	printCommands(sh.out, "", commands)
+	fmt.Fprintf(sh.out, "  %-45s %s\n", "format table|json|csv", "Change the output format")
+	fmt.Fprintf(sh.out, "  %-45s %s\n", "raw [<command> [payload]]", "Send raw bytes, or enter raw mode without arguments")
+	fmt.Fprintf(sh.out, "  %-45s %s\n", "exit", "Leave raw mode or the shell")
+}
+
+// complete completes the word before pos in line. If there is more than
+// one candidate, it extends the word to their common prefix and prints
+// them.
+func (sh *shell) complete(out io.Writer, line string, pos int) (string, int, bool) {
+	head := line[:pos]
+	start := strings.LastIndexAny(head, " \t") + 1
+	word := head[start:]
+	words := strings.Fields(head[:start])
+
+	candidates := sh.candidates(words, word)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	var insert string
+	if len(candidates) == 1 {
+		insert = candidates[0].insert + " "
+	} else {
+		insert = commonPrefix(candidates)
+		if len(insert) <= len(word) {
+			names := make([]string, len(candidates))
+			for i, c := range candidates {
+				names[i] = c.display
+			}
+			fmt.Fprintln(out, strings.Join(names, "  "))
+			return "", 0, false
+		}
+	}
+
+	newLine := head[:start] + insert + line[pos:]
+	return newLine, start + len(insert), true
+}
+
+// A candidate is a possible completion. display is shown to the user when
+// there is more than one candidate, insert is what replaces the word.
+type candidate struct {
+	insert  string
+	display string
+}
+
+// candidates returns the completions of word, which follows words.
+func (sh *shell) candidates(words []string, word string) []candidate {
+	var kind argKind
+	var names []string
+
+	switch {
+	case sh.raw && len(words) == 0:
+		kind = argCommandByte
+	case sh.raw:
+		return nil
+	case len(words) == 0:
+		names = []string{"help", "exit", "format", "raw"}
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+	case words[0] == "format" && len(words) == 1:
+		names = formats
+	case words[0] == "raw" && len(words) == 1:
+		kind = argCommandByte
+	default:
+		cmd, rest := findCommand(commands, words)
+		switch {
+		case cmd == nil:
+			return nil
+		case cmd.run == nil && len(rest) == 0:
+			for _, sub := range cmd.sub {
+				names = append(names, sub.name)
+			}
+		case cmd.run != nil && (len(rest) == 0 || cmd.repeat):
+			kind = cmd.complete
+		default:
+			return nil
+		}
+	}
+
+	switch kind {
+	case argPlayer:
+		return sh.playerCandidates(word)
+	case argClass:
+		for _, class := range rcon.AllClasses {
+			names = append(names, string(class))
+		}
+	case argAIClass:
+		for _, class := range rcon.AllAIClasses {
+			names = append(names, string(class))
+		}
+	case argOnOff:
+		names = []string{"on", "off"}
+	case argCommandByte:
+		for _, mt := range messageTypes {
+			names = append(names, rcon.CommandName(mt))
+		}
+	}
+
+	var candidates []candidate
+	for _, name := range names {
+		if hasPrefixFold(name, word) {
+			candidates = append(candidates, candidate{name, name})
+		}
+	}
+	return candidates
+}
+
+// playerCandidates completes player IDs. The word may be the start of an
+// ID or of a player name, but the completion is always the ID.
+func (sh *shell) playerCandidates(word string) []candidate {
+	var candidates []candidate
+	for _, player := range sh.players {
+		id := string(player.ID)
+		if strings.HasPrefix(id, word) || hasPrefixFold(player.Name, word) {
+			candidates = append(candidates, candidate{id, id + " (" + sanitize(player.Name) + ")"})
+		}
+	}
+	return candidates
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// commonPrefix returns the longest common prefix of the candidates.
+func commonPrefix(candidates []candidate) string {
+	prefix := candidates[0].insert
+	for _, c := range candidates[1:] {
+		n := 0
+		for n < len(prefix) && n < len(c.insert) && prefix[n] == c.insert[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// splitWords splits line at whitespace. Single or double quotes group
+// words, e.g. to pass a kick reason with several spaces.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// unescape interprets the Go escape sequences in s. \xHH produces the
+// byte HH, even if it is not valid UTF-8.
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for len(s) > 0 {
+		value, multibyte, tail, err := strconv.UnquoteChar(s, 0)
+		if err != nil {
+			return "", err
+		}
+		if multibyte {
+			b.WriteRune(value)
+		} else {
+			b.WriteByte(byte(value))
+		}
+		s = tail
+	}
+	return b.String(), nil
+}
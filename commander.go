@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"time"
+)
+
+// A Commander sends commands to a server. It is implemented by [*Client]
+// and [*Pool], so code that only sends commands can work with either.
+type Commander interface {
+	ExecCommandContext(ctx context.Context, command byte, params ...string) (string, error)
+
+	GetPlayerListContext(ctx context.Context) ([]Player, error)
+	GetPlayerDataContext(ctx context.Context) ([]Player, error)
+	GetPlayerDataWithTimestampContext(ctx context.Context) ([]Player, time.Time, error)
+	GetPlayerDataLenientContext(ctx context.Context) ([]Player, []*PlayerDataError, error)
+	GetServerDetailsContext(ctx context.Context) (*ServerDetails, error)
+	TakeSnapshot(ctx context.Context) (*Snapshot, error)
+
+	AnnounceContext(ctx context.Context, message string) error
+	SendDirectMessageContext(ctx context.Context, playerID PlayerID, message string) error
+	KickPlayerContext(ctx context.Context, playerID PlayerID, reason string) error
+	BanPlayerContext(ctx context.Context, playerID PlayerID, reason string, duration time.Duration) error
+	AddWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error
+	RemoveWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error
+
+	SaveContext(ctx context.Context) error
+	WipeCorpsesContext(ctx context.Context) error
+	UpdatePlayablesContext(ctx context.Context, classes []DinoClass) error
+	DisableAIClassesContext(ctx context.Context, classes []AIClass) error
+	SetAIDensityContext(ctx context.Context, density float32) error
+
+	ToggleWhitelistContext(ctx context.Context) (bool, error)
+	ToggleGlobalChatContext(ctx context.Context) (bool, error)
+	ToggleHumansContext(ctx context.Context) (bool, error)
+	ToggleAIContext(ctx context.Context) (bool, error)
+	SetWhitelistContext(ctx context.Context, on bool) (bool, error)
+	SetGlobalChatContext(ctx context.Context, on bool) (bool, error)
+	SetHumansContext(ctx context.Context, on bool) (bool, error)
+	SetAIContext(ctx context.Context, on bool) (bool, error)
+
+	// Stats returns the statistics of the commands sent so far.
+	Stats() Stats
+}
+
+var (
+	_ Commander = (*Client)(nil)
+	_ Commander = (*Pool)(nil)
+)
@@ -17,7 +17,99 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 package theislercon
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/butt4cak3/theislercon/internal/parser"
+)
 
 var ErrIncorrectPassword = errors.New("incorrect password")
 var ErrMalformedResponse = errors.New("malformed response")
+var ErrReconnectFailed = errors.New("reconnect failed")
+var ErrResponseTooLarge = errors.New("response too large")
+var ErrInvalidPlayerID = errors.New("invalid player ID")
+var ErrCommandFailed = errors.New("command failed")
+var ErrUnexpectedState = errors.New("unexpected state")
+var ErrArgumentSeparator = errors.New("argument contains separator")
+var ErrArgumentTooLong = errors.New("argument too long")
+var ErrNonPrintableArgument = errors.New("argument contains non-printable characters")
+
+// ErrBroken is returned by a request if the connection broke earlier,
+// e.g. because of a timeout, and connecting again failed. Without a
+// [ReconnectPolicy], the client makes a single attempt at connecting
+// again for every request. See [Client.EnableReconnect].
+var ErrBroken = errors.New("connection broken")
+
+// ErrWatcherStarted is returned by [Watcher.Run] if the watcher has already
+// been run.
+var ErrWatcherStarted = errors.New("watcher already started")
+
+// A ParseError describes a response that could not be parsed.
+//
+// ParseError matches [ErrMalformedResponse] with [errors.Is].
+type ParseError struct {
+	Command  MessageType // The command that the response belongs to
+	Response string      // The complete response as sent by the server
+	Offset   int         // Byte offset in Response at which parsing failed
+	Expected string      // What the parser expected at Offset
+	Field    string      // The field that was being parsed, if known
+	Err      error       // The underlying error, if any
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("%v to command 0x%02x at byte %d", ErrMalformedResponse, e.Command, e.Offset)
+	if e.Field != "" {
+		s += " in " + e.Field
+	}
+	s += ": expected " + e.Expected
+	if e.Offset < len(e.Response) {
+		s += fmt.Sprintf(", got %q", snippet(e.Response[e.Offset:]))
+	} else {
+		s += ", got end of response"
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *ParseError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrMalformedResponse, e.Err}
+	}
+	return []error{ErrMalformedResponse}
+}
+
+// newParseError turns an error from the parser into a [*ParseError].
+//
+// The parser reports the remaining input at the point of failure. Because
+// the parser only ever works on suffixes of the response, the offset is
+// the difference between the lengths.
+func newParseError(command MessageType, response string, err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+
+	result := &ParseError{Command: command, Response: response, Err: err}
+
+	var e *parser.Error
+	if errors.As(err, &e) {
+		result.Offset = len(response) - len(e.Rest)
+		result.Expected = e.Expected
+		result.Field = e.Field
+		result.Err = e.Err
+	}
+
+	return result
+}
+
+// snippet returns the first few bytes of s for error messages.
+func snippet(s string) string {
+	const n = 20
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
@@ -0,0 +1,242 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package exporter exposes the state of a The Isle Evrima server as
+// Prometheus metrics.
+//
+// Every scrape sends GetServerDetails and GetPlayerData to the server, so
+// the scrape interval should not be too short. The metrics are written in
+// the Prometheus text exposition format; no client library is needed.
+package exporter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// DefaultTimeout is the time that a scrape may take if Exporter.Timeout is
+// not set.
+const DefaultTimeout = 10 * time.Second
+
+// An Exporter is an [http.Handler] that serves metrics about the server
+// that Client is connected to.
+type Exporter struct {
+	Client rcon.Commander
+
+	// Timeout limits the time spent talking to the server per scrape. The
+	// default is DefaultTimeout.
+	Timeout time.Duration
+}
+
+// New returns an exporter for client.
+func New(client rcon.Commander) *Exporter {
+	return &Exporter{Client: client}
+}
+
+// ServeHTTP scrapes the server and writes the metrics.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	e.WriteMetrics(r.Context(), &buf)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// WriteMetrics scrapes the server and writes the metrics to buf.
+//
+// If the server cannot be reached, theisle_up is 0 and only the metrics of
+// the client are written.
+func (e *Exporter) WriteMetrics(ctx context.Context, buf *bytes.Buffer) {
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(e.Timeout, DefaultTimeout))
+	defer cancel()
+
+	start := time.Now()
+	details, players, skipped, err := e.scrape(ctx)
+	duration := time.Since(start)
+
+	m := &metricWriter{buf: buf}
+
+	m.header("theisle_up", "gauge", "Whether the last scrape of the server succeeded.")
+	m.sample("theisle_up", nil, boolValue(err == nil))
+	m.header("theisle_scrape_duration_seconds", "gauge", "Time it took to scrape the server.")
+	m.sample("theisle_scrape_duration_seconds", nil, duration.Seconds())
+
+	if err == nil {
+		writeServerMetrics(m, details)
+		writePlayerMetrics(m, players, skipped)
+	}
+
+	writeClientMetrics(m, e.Client.Stats())
+}
+
+func (e *Exporter) scrape(ctx context.Context) (*rcon.ServerDetails, []rcon.Player, int, error) {
+	details, err := e.Client.GetServerDetailsContext(ctx)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	players, skipped, err := e.Client.GetPlayerDataLenientContext(ctx)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return details, players, len(skipped), nil
+}
+
+func writeServerMetrics(m *metricWriter, details *rcon.ServerDetails) {
+	m.header("theisle_server_info", "gauge", "Name and map of the server. The value is always 1.")
+	m.sample("theisle_server_info", []label{{"name", details.Name}, {"map", details.Map}}, 1)
+
+	m.header("theisle_players", "gauge", "Number of connected players as reported by the server.")
+	m.sample("theisle_players", nil, float64(details.CurrentPlayers))
+	m.header("theisle_max_players", "gauge", "Maximum number of players.")
+	m.sample("theisle_max_players", nil, float64(details.MaxPlayers))
+
+	m.header("theisle_day_length_minutes", "gauge", "Length of a day in minutes.")
+	m.sample("theisle_day_length_minutes", nil, float64(details.DayLengthMinutes))
+	m.header("theisle_night_length_minutes", "gauge", "Length of a night in minutes.")
+	m.sample("theisle_night_length_minutes", nil, float64(details.NightLengthMinutes))
+
+	toggles := []struct {
+		name string
+		on   bool
+	}{
+		{"whitelist", details.Whitelist},
+		{"global_chat", details.EnableGlobalChat},
+		{"humans", details.EnableHumans},
+		{"ai", details.SpawnAI},
+		{"mutations", details.EnableMutations},
+		{"queue", details.QueueEnabled},
+		{"password", details.HasPassword},
+		{"recording_gameplay", details.AllowRecordingGameplay},
+		{"region_spawning", details.UseRegionSpawning},
+		{"region_spawn_cooldown", details.UseRegionSpawnCooldown},
+	}
+	m.header("theisle_toggle_enabled", "gauge", "Whether a server setting is turned on.")
+	for _, toggle := range toggles {
+		m.sample("theisle_toggle_enabled", []label{{"toggle", toggle.name}}, boolValue(toggle.on))
+	}
+
+	if !details.Timestamp.IsZero() {
+		m.header("theisle_server_time_offset_seconds", "gauge", "Difference between the clock of the server and the clock of the exporter.")
+		m.sample("theisle_server_time_offset_seconds", nil, details.Timestamp.Sub(time.Now()).Seconds())
+	}
+}
+
+func writePlayerMetrics(m *metricWriter, players []rcon.Player, skipped int) {
+	counts := make(map[rcon.DinoClass]int)
+	for _, class := range rcon.AllClasses {
+		counts[class] = 0
+	}
+	for _, player := range players {
+		counts[player.DinoClass]++
+	}
+	classes := make([]rcon.DinoClass, 0, len(counts))
+	for class := range counts {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+
+	m.header("theisle_spawned_players", "gauge", "Number of players in the player data, i.e. players that have spawned.")
+	m.sample("theisle_spawned_players", nil, float64(len(players)))
+
+	m.header("theisle_players_by_class", "gauge", "Number of spawned players per class.")
+	for _, class := range classes {
+		m.sample("theisle_players_by_class", []label{{"class", string(class)}}, float64(counts[class]))
+	}
+
+	stats := []struct {
+		name  string
+		value func(rcon.Player) int8
+	}{
+		{"growth", func(p rcon.Player) int8 { return p.Growth }},
+		{"health", func(p rcon.Player) int8 { return p.Health }},
+		{"stamina", func(p rcon.Player) int8 { return p.Stamina }},
+		{"hunger", func(p rcon.Player) int8 { return p.Hunger }},
+		{"thirst", func(p rcon.Player) int8 { return p.Thirst }},
+	}
+	if len(players) > 0 {
+		m.header("theisle_player_stat_average_ratio", "gauge", "Average of a stat over all spawned players, from 0 to 1.")
+		for _, stat := range stats {
+			sum := 0
+			for _, player := range players {
+				sum += int(stat.value(player))
+			}
+			average := float64(sum) / float64(len(players)) / 100
+			m.sample("theisle_player_stat_average_ratio", []label{{"stat", stat.name}}, average)
+		}
+	}
+
+	m.header("theisle_player_data_skipped", "gauge", "Number of player records in the last scrape that could not be parsed.")
+	m.sample("theisle_player_data_skipped", nil, float64(skipped))
+}
+
+func writeClientMetrics(m *metricWriter, stats rcon.Stats) {
+	commands := make([]rcon.MessageType, 0, len(stats.Commands))
+	for command := range stats.Commands {
+		commands = append(commands, command)
+	}
+	slices.Sort(commands)
+
+	m.header("theisle_rcon_requests_total", "counter", "Number of RCON requests sent, by command.")
+	for _, command := range commands {
+		m.sample("theisle_rcon_requests_total", commandLabel(command), float64(stats.Commands[command].Requests))
+	}
+
+	m.header("theisle_rcon_errors_total", "counter", "Number of RCON requests that got no response, by command.")
+	for _, command := range commands {
+		m.sample("theisle_rcon_errors_total", commandLabel(command), float64(stats.Commands[command].Errors))
+	}
+
+	m.header("theisle_rcon_request_duration_seconds", "summary", "Time from sending RCON requests until their response arrived.")
+	for _, command := range commands {
+		s := stats.Commands[command]
+		m.sample("theisle_rcon_request_duration_seconds_sum", commandLabel(command), s.Duration.Seconds())
+		m.sample("theisle_rcon_request_duration_seconds_count", commandLabel(command), float64(s.Requests))
+	}
+
+	m.header("theisle_rcon_queue_wait_seconds_total", "counter", "Time that RCON requests spent in the queue before they were sent, by command.")
+	for _, command := range commands {
+		m.sample("theisle_rcon_queue_wait_seconds_total", commandLabel(command), stats.Commands[command].Wait.Seconds())
+	}
+
+	m.header("theisle_rcon_coalesced_total", "counter", "Number of calls that shared the response of an identical queued request, by command.")
+	for _, command := range commands {
+		m.sample("theisle_rcon_coalesced_total", commandLabel(command), float64(stats.Commands[command].Coalesced))
+	}
+
+	m.header("theisle_rcon_queue_depth", "gauge", "Number of RCON commands waiting in the queue.")
+	m.sample("theisle_rcon_queue_depth", nil, float64(stats.QueueDepth))
+
+	m.header("theisle_rcon_reconnects_total", "counter", "Number of times the client connected again after the connection broke.")
+	m.sample("theisle_rcon_reconnects_total", nil, float64(stats.Reconnects))
+}
+
+func commandLabel(command rcon.MessageType) []label {
+	return []label{{"command", rcon.CommandName(command)}}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
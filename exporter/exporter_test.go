@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package exporter
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestExporter(t *testing.T) {
+	players := []rcon.Player{
+		{ID: "76561198000000001", Name: "Alice", DinoClass: rcon.Troodon, Growth: 50, Health: 100, Stamina: 100, Hunger: 80, Thirst: 60},
+		{ID: "76561198000000002", Name: "Bob", DinoClass: rcon.Troodon, Growth: 100, Health: 50, Stamina: 100, Hunger: 40, Thirst: 20},
+		{ID: "76561198000000003", Name: "Carol", DinoClass: rcon.Stegosaurus, Growth: 75, Health: 75, Stamina: 100, Hunger: 60, Thirst: 40},
+		// Dave has not spawned yet.
+		{ID: "76561198000000004", Name: "Dave"},
+	}
+
+	tests := []struct {
+		name string
+		// stop shuts the server down before the scrape.
+		stop      bool
+		want      []string
+		wantNotIn []string
+	}{
+		{
+			name: "up",
+			want: []string{
+				"theisle_up 1\n",
+				`theisle_server_info{name="rcontest",map="Gateway"} 1` + "\n",
+				"theisle_players 4\n",
+				"theisle_max_players 100\n",
+				"theisle_spawned_players 3\n",
+				`theisle_players_by_class{class="Troodon"} 2` + "\n",
+				`theisle_players_by_class{class="Stegosaurus"} 1` + "\n",
+				`theisle_players_by_class{class="Omniraptor"} 0` + "\n",
+				`theisle_player_stat_average_ratio{stat="growth"} 0.75` + "\n",
+				`theisle_player_stat_average_ratio{stat="thirst"} 0.4` + "\n",
+				`theisle_toggle_enabled{toggle="whitelist"} 0` + "\n",
+				`theisle_toggle_enabled{toggle="global_chat"} 1` + "\n",
+				"theisle_player_data_skipped 0\n",
+				`theisle_rcon_requests_total{command="GetServerDetails"} 1` + "\n",
+				`theisle_rcon_requests_total{command="GetPlayerData"} 1` + "\n",
+				`theisle_rcon_errors_total{command="GetPlayerData"} 0` + "\n",
+				"theisle_rcon_reconnects_total 0\n",
+			},
+		},
+		{
+			name: "down",
+			stop: true,
+			want: []string{
+				"theisle_up 0\n",
+				`theisle_rcon_errors_total{command="GetServerDetails"} 1` + "\n",
+			},
+			wantNotIn: []string{"theisle_players ", "theisle_toggle_enabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rcontest.NewServer("password")
+			defer server.Close()
+			server.Update(func(world *rcontest.World) { world.Players = players })
+
+			client, err := rcon.Connect(server.Addr)
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+			if err := client.Auth("password"); err != nil {
+				t.Fatalf("Auth: %v", err)
+			}
+			if tt.stop {
+				server.Close()
+			}
+
+			rec := httptest.NewRecorder()
+			New(client).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			body := rec.Body.String()
+
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+				t.Errorf("Content-Type %q", ct)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("metrics do not contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.wantNotIn {
+				if strings.Contains(body, unwanted) {
+					t.Errorf("metrics contain %q", unwanted)
+				}
+			}
+			if t.Failed() {
+				t.Logf("metrics:\n%s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+// metricWriter writes metrics in the Prometheus text exposition format.
+type metricWriter struct {
+	buf *bytes.Buffer
+}
+
+// header writes the HELP and TYPE lines of a metric.
+func (m *metricWriter) header(name, typ, help string) {
+	m.buf.WriteString("# HELP " + name + " " + escapeHelp(help) + "\n")
+	m.buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// sample writes one sample of a metric.
+func (m *metricWriter) sample(name string, labels []label, value float64) {
+	m.buf.WriteString(name)
+	if len(labels) > 0 {
+		m.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				m.buf.WriteByte(',')
+			}
+			m.buf.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+		}
+		m.buf.WriteByte('}')
+	}
+	m.buf.WriteByte(' ')
+	m.buf.WriteString(formatValue(value))
+	m.buf.WriteByte('\n')
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,347 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerConfig describes one server of a [Fleet].
+type ServerConfig struct {
+	// Name identifies the server within the fleet.
+	Name    string `json:"name"`
+	Address string `json:"address"`
+
+	// Password is the RCON password. If PasswordEnv is set instead, the
+	// password is read from that environment variable.
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"passwordEnv,omitempty"`
+
+	// Tags group servers, e.g. by region or game mode.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// ReadFleetConfig reads the servers of a fleet from JSON:
+//
+//	{
+//	  "servers": [
+//	    {"name": "eu-1", "address": "203.0.113.7:8888", "passwordEnv": "EU1_PASSWORD", "tags": ["eu"]},
+//	    {"name": "us-1", "address": "198.51.100.3:8888", "password": "secret", "tags": ["us"]}
+//	  ]
+//	}
+func ReadFleetConfig(r io.Reader) ([]ServerConfig, error) {
+	var config struct {
+		Servers []ServerConfig `json:"servers"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("fleet config: %w", err)
+	}
+	return config.Servers, nil
+}
+
+// A Fleet manages the clients of several servers and runs commands on
+// many of them at once.
+//
+// The servers are connected to on first use, so a server that is down
+// does not keep the fleet from working with the others. The clients
+// reconnect automatically; the options given to [NewFleet] may replace
+// the [ReconnectPolicy].
+type Fleet struct {
+	members []*fleetMember
+	opts    []Option
+}
+
+type fleetMember struct {
+	config ServerConfig
+
+	mu     sync.Mutex
+	client *Client
+	closed bool
+}
+
+// NewFleet returns a fleet of the given servers. Every client is created
+// with opts. NewFleet does not connect to the servers; see
+// [Fleet.Connect].
+func NewFleet(servers []ServerConfig, opts ...Option) (*Fleet, error) {
+	fleet := &Fleet{
+		opts: append([]Option{WithReconnect(ReconnectPolicy{MaxAttempts: 3})}, opts...),
+	}
+
+	seen := make(map[string]bool)
+	for _, server := range servers {
+		switch {
+		case server.Name == "":
+			return nil, fmt.Errorf("fleet: server with address %q has no name", server.Address)
+		case seen[server.Name]:
+			return nil, fmt.Errorf("fleet: duplicate server name %q", server.Name)
+		case server.Address == "":
+			return nil, fmt.Errorf("fleet: server %q has no address", server.Name)
+		}
+		seen[server.Name] = true
+		fleet.members = append(fleet.members, &fleetMember{config: server})
+	}
+
+	return fleet, nil
+}
+
+// Servers returns the configuration of every server in the fleet.
+func (fleet *Fleet) Servers() []ServerConfig {
+	servers := make([]ServerConfig, len(fleet.members))
+	for i, member := range fleet.members {
+		servers[i] = member.config
+	}
+	return servers
+}
+
+// Client returns the client of the named server, connecting to it first
+// if necessary.
+func (fleet *Fleet) Client(ctx context.Context, name string) (*Client, error) {
+	for _, member := range fleet.members {
+		if member.config.Name == name {
+			return member.connect(ctx, fleet.opts)
+		}
+	}
+	return nil, fmt.Errorf("fleet: unknown server %q", name)
+}
+
+// Connect connects to every server that is not connected yet. The
+// returned error is a [*FleetError] if any server failed.
+func (fleet *Fleet) Connect(ctx context.Context) error {
+	return fleet.Do(ctx, All(), func(ctx context.Context, client *Client) error {
+		return nil
+	})
+}
+
+// Close closes the clients of all servers.
+func (fleet *Fleet) Close() error {
+	var errs []error
+	for _, member := range fleet.members {
+		member.mu.Lock()
+		member.closed = true
+		if member.client != nil {
+			if err := member.client.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		member.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (member *fleetMember) connect(ctx context.Context, opts []Option) (*Client, error) {
+	member.mu.Lock()
+	defer member.mu.Unlock()
+
+	if member.closed {
+		return nil, errFleetClosed
+	}
+	if member.client != nil {
+		return member.client, nil
+	}
+
+	client, err := ConnectContext(ctx, member.config.Address, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.AuthContext(ctx, member.config.password()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	member.client = client
+	return client, nil
+}
+
+var errFleetClosed = errors.New("fleet: closed")
+
+func (config ServerConfig) password() string {
+	if config.PasswordEnv != "" {
+		return os.Getenv(config.PasswordEnv)
+	}
+	return config.Password
+}
+
+// A Selector chooses the servers of a fleet that an operation runs on.
+type Selector func(ServerConfig) bool
+
+// All selects every server.
+func All() Selector {
+	return func(ServerConfig) bool { return true }
+}
+
+// Named selects the servers with the given names.
+func Named(names ...string) Selector {
+	return func(config ServerConfig) bool { return slices.Contains(names, config.Name) }
+}
+
+// Tagged selects the servers that have all of the given tags.
+func Tagged(tags ...string) Selector {
+	return func(config ServerConfig) bool {
+		for _, tag := range tags {
+			if !slices.Contains(config.Tags, tag) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// A FleetResult is the outcome of an operation on one server.
+type FleetResult[T any] struct {
+	Server string
+	Value  T
+	Err    error
+}
+
+// FleetResults are the outcomes of an operation on several servers, in
+// the order of the fleet configuration.
+type FleetResults[T any] []FleetResult[T]
+
+// Err returns a [*FleetError] with the errors of the failed servers, or
+// nil if no server failed.
+func (results FleetResults[T]) Err() error {
+	var fleetErr FleetError
+	for _, result := range results {
+		if result.Err != nil {
+			fleetErr.Servers = append(fleetErr.Servers, result.Server)
+			fleetErr.Errors = append(fleetErr.Errors, result.Err)
+		}
+	}
+	if len(fleetErr.Servers) == 0 {
+		return nil
+	}
+	return &fleetErr
+}
+
+// A FleetError contains the errors of the servers on which an operation
+// failed.
+type FleetError struct {
+	Servers []string // Names of the failed servers
+	Errors  []error  // Errors[i] is the error of Servers[i]
+}
+
+func (e *FleetError) Error() string {
+	parts := make([]string, len(e.Servers))
+	for i, server := range e.Servers {
+		parts[i] = server + ": " + e.Errors[i].Error()
+	}
+	return "fleet: " + strings.Join(parts, "; ")
+}
+
+func (e *FleetError) Unwrap() []error {
+	return e.Errors
+}
+
+// RunFleet calls fn with the client of every selected server
+// concurrently and collects the results.
+func RunFleet[T any](ctx context.Context, fleet *Fleet, selector Selector, fn func(ctx context.Context, client *Client) (T, error)) FleetResults[T] {
+	var results FleetResults[T]
+	var members []*fleetMember
+	for _, member := range fleet.members {
+		if selector(member.config) {
+			members = append(members, member)
+			results = append(results, FleetResult[T]{Server: member.config.Name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := member.connect(ctx, fleet.opts)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			results[i].Value, results[i].Err = fn(ctx, client)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Do calls fn with the client of every selected server concurrently. The
+// returned error is a [*FleetError] if fn failed for any server.
+func (fleet *Fleet) Do(ctx context.Context, selector Selector, fn func(ctx context.Context, client *Client) error) error {
+	return RunFleet(ctx, fleet, selector, func(ctx context.Context, client *Client) (struct{}, error) {
+		return struct{}{}, fn(ctx, client)
+	}).Err()
+}
+
+// Announce sends an announcement to the selected servers.
+func (fleet *Fleet) Announce(ctx context.Context, selector Selector, message string) error {
+	return fleet.Do(ctx, selector, func(ctx context.Context, client *Client) error {
+		return client.AnnounceContext(ctx, message)
+	})
+}
+
+// AddWhitelistID adds players to the whitelists of the selected servers.
+func (fleet *Fleet) AddWhitelistID(ctx context.Context, selector Selector, playerID ...PlayerID) error {
+	return fleet.Do(ctx, selector, func(ctx context.Context, client *Client) error {
+		return client.AddWhitelistIDContext(ctx, playerID...)
+	})
+}
+
+// RemoveWhitelistID removes players from the whitelists of the selected
+// servers.
+func (fleet *Fleet) RemoveWhitelistID(ctx context.Context, selector Selector, playerID ...PlayerID) error {
+	return fleet.Do(ctx, selector, func(ctx context.Context, client *Client) error {
+		return client.RemoveWhitelistIDContext(ctx, playerID...)
+	})
+}
+
+// BanPlayer bans a player from the selected servers.
+func (fleet *Fleet) BanPlayer(ctx context.Context, selector Selector, playerID PlayerID, reason string, duration time.Duration) error {
+	return fleet.Do(ctx, selector, func(ctx context.Context, client *Client) error {
+		return client.BanPlayerContext(ctx, playerID, reason, duration)
+	})
+}
+
+// GetPlayerList returns the players of every selected server.
+func (fleet *Fleet) GetPlayerList(ctx context.Context, selector Selector) FleetResults[[]Player] {
+	return RunFleet(ctx, fleet, selector, func(ctx context.Context, client *Client) ([]Player, error) {
+		return client.GetPlayerListContext(ctx)
+	})
+}
+
+// FindPlayer returns the names of the servers that the player is
+// connected to. Servers that could not be asked are reported in a
+// [*FleetError], together with the servers that were found.
+func (fleet *Fleet) FindPlayer(ctx context.Context, playerID PlayerID) ([]string, error) {
+	results := fleet.GetPlayerList(ctx, All())
+
+	var servers []string
+	for _, result := range results {
+		if slices.ContainsFunc(result.Value, func(p Player) bool { return p.ID == playerID }) {
+			servers = append(servers, result.Server)
+		}
+	}
+	return servers, results.Err()
+}
@@ -0,0 +1,376 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+// fastReconnect keeps the tests from waiting for servers that are down.
+var fastReconnect = rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 1, InitialBackoff: time.Millisecond})
+
+// newFleet starts a fake server for every config and returns a fleet of
+// them. Configs without an address get the address of their server; a
+// config with the address "down" gets the address of a closed server.
+func newFleet(t *testing.T, configs ...rcon.ServerConfig) (map[string]*rcontest.Server, *rcon.Fleet) {
+	t.Helper()
+
+	servers := make(map[string]*rcontest.Server)
+	for i, config := range configs {
+		server := rcontest.NewServer("password")
+		t.Cleanup(server.Close)
+		if config.Address == "down" {
+			server.Close()
+		} else {
+			servers[config.Name] = server
+		}
+		configs[i].Address = server.Addr
+		if config.Password == "" && config.PasswordEnv == "" {
+			configs[i].Password = "password"
+		}
+	}
+
+	fleet, err := rcon.NewFleet(configs, fastReconnect)
+	if err != nil {
+		t.Fatalf("NewFleet: %v", err)
+	}
+	t.Cleanup(func() { fleet.Close() })
+	return servers, fleet
+}
+
+func TestNewFleet(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []rcon.ServerConfig
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"valid", []rcon.ServerConfig{{Name: "eu-1", Address: "a:1"}, {Name: "us-1", Address: "b:1"}}, ""},
+		{"no name", []rcon.ServerConfig{{Address: "a:1"}}, "has no name"},
+		{"no address", []rcon.ServerConfig{{Name: "eu-1"}}, "has no address"},
+		{"duplicate name", []rcon.ServerConfig{{Name: "eu-1", Address: "a:1"}, {Name: "eu-1", Address: "b:1"}}, "duplicate server name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fleet, err := rcon.NewFleet(tt.servers)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewFleet returned error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFleet: %v", err)
+			}
+			if got := fleet.Servers(); len(got) != len(tt.servers) {
+				t.Errorf("fleet has %d servers, want %d", len(got), len(tt.servers))
+			}
+		})
+	}
+}
+
+func TestReadFleetConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []rcon.ServerConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			json: `{"servers": [
+				{"name": "eu-1", "address": "203.0.113.7:8888", "passwordEnv": "EU1_PASSWORD", "tags": ["eu"]},
+				{"name": "us-1", "address": "198.51.100.3:8888", "password": "secret"}
+			]}`,
+			want: []rcon.ServerConfig{
+				{Name: "eu-1", Address: "203.0.113.7:8888", PasswordEnv: "EU1_PASSWORD", Tags: []string{"eu"}},
+				{Name: "us-1", Address: "198.51.100.3:8888", Password: "secret"},
+			},
+		},
+		{
+			name: "no servers",
+			json: `{}`,
+			want: nil,
+		},
+		{
+			name:    "unknown field",
+			json:    `{"servers": [{"name": "eu-1", "adress": "203.0.113.7:8888"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			json:    `{"servers": [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rcon.ReadFleetConfig(strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFleetConfig returned error %v, want error: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFleetConfig =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFleetPasswordEnv(t *testing.T) {
+	t.Setenv("FLEET_TEST_PASSWORD", "password")
+	_, fleet := newFleet(t, rcon.ServerConfig{Name: "eu-1", PasswordEnv: "FLEET_TEST_PASSWORD"})
+
+	if err := fleet.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestFleetSelectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector rcon.Selector
+		want     []string
+	}{
+		{"all", rcon.All(), []string{"eu-1", "eu-2", "us-1"}},
+		{"named", rcon.Named("us-1", "eu-1"), []string{"eu-1", "us-1"}},
+		{"named unknown", rcon.Named("asia-1"), nil},
+		{"tagged", rcon.Tagged("eu"), []string{"eu-1", "eu-2"}},
+		{"tagged with all", rcon.Tagged("eu", "pvp"), []string{"eu-2"}},
+		{"no tags", rcon.Tagged(), []string{"eu-1", "eu-2", "us-1"}},
+	}
+
+	_, fleet := newFleet(t,
+		rcon.ServerConfig{Name: "eu-1", Tags: []string{"eu"}},
+		rcon.ServerConfig{Name: "eu-2", Tags: []string{"eu", "pvp"}},
+		rcon.ServerConfig{Name: "us-1", Tags: []string{"us", "pvp"}},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := rcon.RunFleet(context.Background(), fleet, tt.selector, func(ctx context.Context, client *rcon.Client) (bool, error) {
+				return true, nil
+			})
+			if err := results.Err(); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Server)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFleetCommands(t *testing.T) {
+	const playerID = rcon.PlayerID("76561198000000001")
+
+	tests := []struct {
+		name  string
+		run   func(ctx context.Context, fleet *rcon.Fleet) error
+		check func(world *rcontest.World) bool
+		want  []string
+	}{
+		{
+			name: "announce",
+			run: func(ctx context.Context, fleet *rcon.Fleet) error {
+				return fleet.Announce(ctx, rcon.Tagged("eu"), "Restart in 5 minutes")
+			},
+			check: func(world *rcontest.World) bool {
+				return slices.Equal(world.Announcements, []string{"Restart in 5 minutes"})
+			},
+			want: []string{"eu-1", "eu-2"},
+		},
+		{
+			name: "add to whitelist",
+			run: func(ctx context.Context, fleet *rcon.Fleet) error {
+				return fleet.AddWhitelistID(ctx, rcon.All(), playerID)
+			},
+			check: func(world *rcontest.World) bool {
+				return slices.Contains(world.Whitelist, playerID)
+			},
+			want: []string{"eu-1", "eu-2", "us-1"},
+		},
+		{
+			name: "remove from whitelist",
+			run: func(ctx context.Context, fleet *rcon.Fleet) error {
+				if err := fleet.AddWhitelistID(ctx, rcon.All(), playerID); err != nil {
+					return err
+				}
+				return fleet.RemoveWhitelistID(ctx, rcon.Named("us-1"), playerID)
+			},
+			check: func(world *rcontest.World) bool {
+				return !slices.Contains(world.Whitelist, playerID)
+			},
+			want: []string{"us-1"},
+		},
+		{
+			name: "ban",
+			run: func(ctx context.Context, fleet *rcon.Fleet) error {
+				return fleet.BanPlayer(ctx, rcon.Named("eu-2"), playerID, "griefing", time.Hour)
+			},
+			check: func(world *rcontest.World) bool {
+				return slices.Equal(world.Bans, []rcontest.Ban{{PlayerID: playerID, Reason: "griefing", Minutes: 60}})
+			},
+			want: []string{"eu-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers, fleet := newFleet(t,
+				rcon.ServerConfig{Name: "eu-1", Tags: []string{"eu"}},
+				rcon.ServerConfig{Name: "eu-2", Tags: []string{"eu"}},
+				rcon.ServerConfig{Name: "us-1", Tags: []string{"us"}},
+			)
+
+			if err := tt.run(context.Background(), fleet); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, config := range fleet.Servers() {
+				servers[config.Name].Update(func(world *rcontest.World) {
+					if tt.check(world) {
+						got = append(got, config.Name)
+					}
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changed servers %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFleetFindPlayer(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      rcon.PlayerID
+		want    []string
+		wantErr []string
+	}{
+		{"one server", alice.ID, []string{"eu-1"}, []string{"down"}},
+		{"two servers", bob.ID, []string{"eu-1", "us-1"}, []string{"down"}},
+		{"not found", "76561198000000003", nil, []string{"down"}},
+	}
+
+	servers, fleet := newFleet(t,
+		rcon.ServerConfig{Name: "eu-1"},
+		rcon.ServerConfig{Name: "down", Address: "down"},
+		rcon.ServerConfig{Name: "us-1"},
+	)
+	servers["eu-1"].Update(func(world *rcontest.World) {
+		world.AddPlayer(alice)
+		world.AddPlayer(bob)
+	})
+	servers["us-1"].Update(func(world *rcontest.World) {
+		world.AddPlayer(bob)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fleet.FindPlayer(context.Background(), tt.id)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPlayer = %v, want %v", got, tt.want)
+			}
+			var fleetErr *rcon.FleetError
+			if !errors.As(err, &fleetErr) {
+				t.Fatalf("FindPlayer returned error %v, want a *FleetError", err)
+			}
+			if !reflect.DeepEqual(fleetErr.Servers, tt.wantErr) {
+				t.Errorf("failed servers = %v, want %v", fleetErr.Servers, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFleetError(t *testing.T) {
+	servers, fleet := newFleet(t,
+		rcon.ServerConfig{Name: "eu-1"},
+		rcon.ServerConfig{Name: "down", Address: "down"},
+		rcon.ServerConfig{Name: "us-1"},
+	)
+
+	err := fleet.Announce(context.Background(), rcon.All(), "Hello")
+	var fleetErr *rcon.FleetError
+	if !errors.As(err, &fleetErr) {
+		t.Fatalf("Announce returned error %v, want a *FleetError", err)
+	}
+	if !slices.Equal(fleetErr.Servers, []string{"down"}) || len(fleetErr.Errors) != 1 {
+		t.Errorf("FleetError = %+v, want only the server that is down", fleetErr)
+	}
+	if !strings.HasPrefix(err.Error(), "fleet: down: ") {
+		t.Errorf("error message %q does not name the server", err)
+	}
+
+	// The servers that are up got the announcement anyway.
+	for _, name := range []string{"eu-1", "us-1"} {
+		servers[name].Update(func(world *rcontest.World) {
+			if !slices.Equal(world.Announcements, []string{"Hello"}) {
+				t.Errorf("%s: announcements = %q", name, world.Announcements)
+			}
+		})
+	}
+
+	if err := fleet.Announce(context.Background(), rcon.Named("eu-1", "us-1"), "Hello"); err != nil {
+		t.Errorf("Announce to the servers that are up: %v", err)
+	}
+}
+
+func TestFleetClient(t *testing.T) {
+	_, fleet := newFleet(t, rcon.ServerConfig{Name: "eu-1"})
+	ctx := context.Background()
+
+	client, err := fleet.Client(ctx, "eu-1")
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	if again, _ := fleet.Client(ctx, "eu-1"); again != client {
+		t.Error("Client returned a new client for the same server")
+	}
+	if _, err := fleet.Client(ctx, "us-1"); err == nil {
+		t.Error("Client returned no error for an unknown server")
+	}
+
+	if err := fleet.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := client.Announce("Hello"); err == nil {
+		t.Error("the client still works after Close")
+	}
+	if _, err := fleet.Client(ctx, "eu-1"); err == nil {
+		t.Error("Client returned no error after Close")
+	}
+	if err := fleet.Announce(ctx, rcon.All(), "Hello"); err == nil {
+		t.Error("Announce returned no error after Close")
+	}
+}
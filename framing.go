@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultMaxResponseSize is the largest response that a [Client] accepts
+// unless configured otherwise. It is plenty for the PlayerData response
+// of a full server.
+const DefaultMaxResponseSize = 1024 * 1024
+
+// DefaultIdleGap is the time that a [Client] waits for more data of a
+// response unless configured otherwise. See [WithIdleGap].
+const DefaultIdleGap = 100 * time.Millisecond
+
+// The server does not tell us how long a response is going to be, and a
+// large response (e.g. PlayerData on a full server) arrives in several TCP
+// segments. How the response is split depends on how the server writes
+// it, so the size of a segment says nothing about whether more is going
+// to follow. Some server versions terminate responses with a NULL byte,
+// but most do not. Therefore, a response is considered complete when it
+// ends with a NULL byte or when the server stopped sending for the idle
+// gap.
+//
+// If more data arrives after a response was considered complete, the
+// response was cut short and the data belongs to it. The client checks
+// for such data before it sends the next request and treats the
+// connection as broken if it finds any, so that the data is not taken
+// for the next response.
+const (
+	readChunkSize = 16 * 1024
+	// lateDataWait is how long the client looks for late data of the
+	// previous response before it sends a request.
+	lateDataWait = time.Millisecond
+)
+
+// SetMaxResponseSize sets the largest response in bytes that the client
+// accepts. Larger responses make the call fail with [ErrResponseTooLarge].
+//
+// A size of zero or less restores [DefaultMaxResponseSize].
+func (client *Client) SetMaxResponseSize(size int) {
+	if size <= 0 {
+		size = DefaultMaxResponseSize
+	}
+	client.sem <- struct{}{}
+	defer client.unlock()
+	client.maxResponseSize = size
+}
+
+// recv reads one complete response from the connection.
+//
+// deadline is the time at which the whole exchange times out. The caller
+// must hold the lock.
+func (client *Client) recv(ctx context.Context, deadline time.Time) ([]byte, error) {
+	res := make([]byte, 0, readChunkSize)
+	chunk := make([]byte, readChunkSize)
+
+	// The first read waits for the server to start responding. It uses the
+	// deadline that exchange set on the connection.
+	idleLimited := false
+
+	for {
+		n, err := client.conn.Read(chunk)
+		res = append(res, chunk[:n]...)
+
+		if len(res) > client.maxResponseSize {
+			return nil, ErrResponseTooLarge
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(res) > 0 {
+				// The server closed the connection after responding.
+				client.broken = true
+				break
+			}
+			if idleLimited && len(res) > 0 && errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+				client.unterminated = true
+				break
+			}
+			return nil, err
+		}
+
+		if n > 0 && res[len(res)-1] == 0 {
+			break
+		}
+
+		gapEnd := time.Now().Add(client.idleGap)
+		idleLimited = deadline.IsZero() || gapEnd.Before(deadline)
+		if idleLimited {
+			client.conn.SetReadDeadline(gapEnd)
+		} else {
+			client.conn.SetReadDeadline(deadline)
+		}
+
+		// Setting the deadline may have undone a cancellation.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return bytes.TrimRight(res, "\x00"), nil
+}
+
+// lateData reports whether data arrived after the previous response was
+// considered complete. Such data is discarded.
+//
+// The caller must hold the lock.
+func (client *Client) lateData() bool {
+	if !client.unterminated {
+		return false
+	}
+	client.unterminated = false
+
+	buf := make([]byte, readChunkSize)
+	client.conn.SetReadDeadline(time.Now().Add(lateDataWait))
+	n, _ := client.conn.Read(buf)
+	return n > 0
+}
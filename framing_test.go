@@ -0,0 +1,197 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// splitServer answers every request with the given parts, pausing for
+// pause between them.
+func splitServer(t *testing.T, pause time.Duration, parts ...string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		buf := make([]byte, 1024)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				return
+			}
+			for i, part := range parts {
+				if i > 0 {
+					time.Sleep(pause)
+				}
+				if _, err := conn.Write([]byte(part)); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestFraming(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+
+	tests := []struct {
+		name    string
+		opts    []rcon.Option
+		pause   time.Duration
+		parts   []string
+		want    string
+		wantErr error
+		// wantReconnects is the number of times that the next command,
+		// which is sent a pause after the first one returned, has to
+		// connect again. It gets the same response as the first one.
+		wantReconnects uint64
+		// maxTime is how long the command may take at most.
+		maxTime time.Duration
+		// minTime is how long the command must take at least.
+		minTime time.Duration
+	}{
+		{
+			name:    "single short segment",
+			parts:   []string{"Announced"},
+			want:    "Announced",
+			minTime: rcon.DefaultIdleGap,
+		},
+		{
+			name:    "terminated",
+			parts:   []string{"Announced\x00"},
+			want:    "Announced",
+			maxTime: 50 * time.Millisecond,
+		},
+		{
+			name:    "long without terminator",
+			parts:   []string{long},
+			want:    long,
+			minTime: rcon.DefaultIdleGap,
+		},
+		{
+			// The header of a response may arrive on its own.
+			name:    "short first segment",
+			pause:   5 * time.Millisecond,
+			parts:   []string{"[2025.06.01-12.00.00] PlayerData\n", "Name: Alice"},
+			want:    "[2025.06.01-12.00.00] PlayerData\nName: Alice",
+			minTime: rcon.DefaultIdleGap,
+		},
+		{
+			name:    "several segments",
+			pause:   20 * time.Millisecond,
+			parts:   []string{long, "abc", "def"},
+			want:    long + "abcdef",
+			minTime: rcon.DefaultIdleGap,
+		},
+		{
+			name:    "several segments terminated",
+			pause:   20 * time.Millisecond,
+			parts:   []string{long, "abc\x00"},
+			want:    long + "abc",
+			maxTime: rcon.DefaultIdleGap,
+		},
+		{
+			// The rest of the response arrives too late to be part of it,
+			// and must not be taken for the next response either.
+			name:           "pause longer than gap",
+			pause:          150 * time.Millisecond,
+			parts:          []string{long, "abc"},
+			want:           long,
+			wantReconnects: 1,
+		},
+		{
+			name:    "custom gap",
+			opts:    []rcon.Option{rcon.WithIdleGap(300 * time.Millisecond)},
+			pause:   150 * time.Millisecond,
+			parts:   []string{long, "abc"},
+			want:    long + "abc",
+			minTime: 450 * time.Millisecond,
+		},
+		{
+			name:           "too large",
+			opts:           []rcon.Option{rcon.WithMaxResponseSize(len(long))},
+			parts:          []string{long, "abc"},
+			wantErr:        rcon.ErrResponseTooLarge,
+			wantReconnects: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := splitServer(t, tt.pause, tt.parts...)
+			client, err := rcon.Connect(addr, tt.opts...)
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+
+			start := time.Now()
+			got, err := client.ExecCommand(rcon.Announce, "hello")
+			took := time.Since(start)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d bytes %.20q, want %d bytes %.20q", len(got), got, len(tt.want), tt.want)
+			}
+			if tt.maxTime > 0 && took > tt.maxTime {
+				t.Errorf("took %v, want at most %v", took, tt.maxTime)
+			}
+			if took < tt.minTime {
+				t.Errorf("took %v, want at least %v", took, tt.minTime)
+			}
+
+			time.Sleep(tt.pause)
+			got, err = client.ExecCommand(rcon.Announce, "hello")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("next command returned error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("next command got %d bytes %.20q, want %d bytes %.20q", len(got), got, len(tt.want), tt.want)
+			}
+			if reconnects := client.Stats().Reconnects; reconnects != tt.wantReconnects {
+				t.Errorf("%d reconnects, want %d", reconnects, tt.wantReconnects)
+			}
+		})
+	}
+}
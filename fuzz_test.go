@@ -0,0 +1,230 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var playerDataSeeds = []string{
+	"[2025.06.01-12.00.00] PlayerData\nName: Alice, PlayerID: 76561198000000001, Location: X=-12345.678 Y=23456.789 Z=-345.5, Class: BP_Troodon_C, Growth: 0.75, Health: 1.00, Stamina: 0.87, Hunger: 0.52, Thirst: 0.31\n",
+	"PlayerData\nName: Bob, the Builder, PlayerID: 76561198000000002, Location: X=1.000 Y=2.000 Z=3.000, Class: BP_Stegosaurus_C, Growth: 0.10, Health: 0.50, Stamina: 1.00, Hunger: 1.00, Thirst: 1.00\nName: Carol: Ü, PlayerID: 76561198000000003, Location: X=0 Y=0 Z=0, Class: BP_Pteranodon_C, Growth: 0.57, Health: 0.57, Stamina: 0.57, Hunger: 0.57, Thirst: 0.57\n",
+	"[2025.06.01-12.00.00] PlayerData\n",
+	"[2025.06.01-12.00.00] PlayerData\nName: Trunc, PlayerID: 5, Location: X=1 Y",
+	"PlayerData\nName: x, PlayerID: 1, Location: X=1 Y=2 Z=3, Class: A, Growth: 0.1, Health: 1, Stamina: 1, Hunger: 0.5, Thirst: 0.5",
+}
+
+var serverDetailsSeeds = []string{
+	"[2025.06.01-12.00.00] ServerDetails\nServerName: My Server, ServerPassword: , ServerMap: Gateway, ServerMaxPlayers: 100, ServerCurrentPlayers: 42, bEnableMutations: true, bEnableHumans: false, bServerPassword: false, bQueueEnabled: true, bServerWhitelist: false, bSpawnAI: true, bAllowRecordingReplay: true, bUseRegionSpawning: true, bUseRegionSpawnCooldown: true, RegionSpawnCooldownTimeSeconds: 30, ServerDayLengthMinutes: 45, ServerNightLengthMinutes: 20, bEnableGlobalChat: true",
+	"ServerDetails ServerName: x",
+	"ServerDetails bSpawnAI: tru",
+	"ServerDetails ServerMaxPlayers: 99999999999999999999999",
+}
+
+var responseSeeds = []string{
+	"[2025.06.01-12.00.00] PlayerList\n76561198000000001,Alice,,\n",
+	"PlayerList76561198000000001,Alice,",
+	"[2025.06.01-12.00.00] ToggleWhitelist Whitelist: On",
+	"[2025.06.01-1",
+	"[",
+	"",
+}
+
+func FuzzParseResponse(f *testing.F) {
+	for _, seed := range responseSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, msg string) {
+		for _, responseType := range []string{"PlayerList", "ToggleWhitelist", ""} {
+			response, err := parseResponse(GetPlayerList, msg, responseType, time.UTC)
+			checkParseError(t, msg, err)
+			if err == nil && len(response.Content) > len(msg) {
+				t.Errorf("content %q is longer than the response %q", response.Content, msg)
+			}
+		}
+
+		_, err := parsePlayerList(msg)
+		checkParseError(t, msg, err)
+
+		_, err = parseToggle(ToggleWhitelist, msg)
+		checkParseError(t, msg, err)
+	})
+}
+
+func FuzzParsePlayerDataMessage(f *testing.F) {
+	for _, seed := range playerDataSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, msg string) {
+		_, _, err := parsePlayerDataMessage(msg, time.UTC)
+		checkParseError(t, msg, err)
+
+		_, players, errs, err := parsePlayerData(msg, true, time.UTC)
+		checkParseError(t, msg, err)
+		if err == nil && players == nil {
+			t.Errorf("lenient parser returned nil players without an error")
+		}
+		for _, recordErr := range errs {
+			checkParseError(t, msg, recordErr)
+		}
+	})
+}
+
+// FuzzPlayerDataRoundTrip formats a player record like the server does and
+// checks that the parser returns the same values.
+func FuzzPlayerDataRoundTrip(f *testing.F) {
+	f.Add("Alice", "76561198000000001", -12345.678, 23456.789, -345.5, int8(75), int8(100))
+	f.Add("Bob, the Builder", "76561198000000002", 1.0, 2.0, 3.0, int8(10), int8(50))
+	f.Add("Carol: Ü", "0002a1b2c3d4e5f6", 0.0, 0.0, 0.0, int8(0), int8(0))
+	f.Add("", "1", 1e6, -1e6, 0.0005, int8(127), int8(-128))
+	f.Add("trailing space ", "x", 0.0, 0.0, 0.0, int8(1), int8(99))
+
+	f.Fuzz(func(t *testing.T, name, id string, x, y, z float64, growth, health int8) {
+		// The format is ambiguous for names that look like the start of
+		// the fields or of the next record, and the leading whitespace of
+		// a name cannot be told apart from the space after "Name:".
+		if name != strings.TrimLeft(name, " \n\r\t") || strings.Contains(name, ", PlayerID:") || strings.Contains(name, "\nName:") {
+			t.Skip()
+		}
+		if _, rest, err := parsePlayerID(id); err != nil || rest != "" {
+			t.Skip()
+		}
+		for _, v := range []float64{x, y, z} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Skip()
+			}
+		}
+
+		record := fmt.Sprintf("Name: %s, PlayerID: %s, Location: X=%.3f Y=%.3f Z=%.3f, Class: BP_Troodon_C, Growth: %.2f, Health: %.2f, Stamina: 1.00, Hunger: 0.50, Thirst: 0.00\n",
+			name, id, x, y, z, float64(growth)/100, float64(health)/100)
+		msg := "[2025.06.01-12.00.00] PlayerData\n" + record + record
+
+		players, _, err := parsePlayerDataMessage(msg, time.UTC)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", msg, err)
+		}
+		if len(players) != 2 {
+			t.Fatalf("parsed %d players from two records in %q", len(players), msg)
+		}
+
+		want := Player{
+			ID:        PlayerID(id),
+			Name:      name,
+			Location:  Location{formatted(x), formatted(y), formatted(z)},
+			DinoClass: Troodon,
+			Growth:    growth,
+			Health:    health,
+			Stamina:   100,
+			Hunger:    50,
+			Thirst:    0,
+		}
+		for _, player := range players {
+			if player != want {
+				t.Errorf("parsed %+v from %q, want %+v", player, record, want)
+			}
+		}
+	})
+}
+
+// formatted returns v as it is parsed after formatting it with three
+// decimals.
+func formatted(v float64) float64 {
+	v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'f', 3, 64), 64)
+	return v
+}
+
+// FuzzPlayerListRoundTrip formats a player list like the server does and
+// checks that the parser returns the same players.
+func FuzzPlayerListRoundTrip(f *testing.F) {
+	f.Add("76561198000000001", "Alice", "76561198000000002", "Bob the Builder")
+	f.Add("0002a1b2c3d4e5f6", " spaces ", "1", "Ü")
+
+	f.Fuzz(func(t *testing.T, id1, name1, id2, name2 string) {
+		// The server does not allow commas in names, and the list has no
+		// way to escape them.
+		for _, s := range []string{id1, name1, id2, name2} {
+			if strings.Contains(s, ",") {
+				t.Skip()
+			}
+		}
+		for _, id := range []string{id1, id2} {
+			if strings.TrimSpace(id) != id || id == "" {
+				t.Skip()
+			}
+		}
+
+		msg := fmt.Sprintf("[2025.06.01-12.00.00] PlayerList\n%s,%s,,\n%s,%s,,\n", id1, name1, id2, name2)
+		players, err := parsePlayerList(msg)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", msg, err)
+		}
+		want := []Player{
+			{ID: PlayerID(id1), Name: strings.TrimSpace(name1)},
+			{ID: PlayerID(id2), Name: strings.TrimSpace(name2)},
+		}
+		if len(players) != len(want) || players[0] != want[0] || players[1] != want[1] {
+			t.Errorf("parsed %+v from %q, want %+v", players, msg, want)
+		}
+	})
+}
+
+func FuzzParseServerDetails(f *testing.F) {
+	for _, seed := range serverDetailsSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, msg string) {
+		_, err := parseServerDetails(msg, time.UTC, false)
+		checkParseError(t, msg, err)
+
+		_, err = parseServerDetails(msg, time.UTC, true)
+		checkParseError(t, msg, err)
+	})
+}
+
+// checkParseError checks that err, if any, is a *ParseError that points
+// into msg.
+func checkParseError(t *testing.T, msg string, err error) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("error is not a *ParseError: %v", err)
+	}
+	if !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("error does not match ErrMalformedResponse: %v", err)
+	}
+	if parseErr.Response != msg {
+		t.Errorf("error contains response %q, want %q", parseErr.Response, msg)
+	}
+	if parseErr.Offset < 0 || parseErr.Offset > len(msg) {
+		t.Errorf("offset %d is outside of the response of length %d", parseErr.Offset, len(msg))
+	}
+	_ = parseErr.Error()
+}
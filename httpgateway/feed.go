@@ -0,0 +1,408 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// subscriberBuffer is the number of messages that may queue up for a
+// subscriber. Subscribers that fall further behind are disconnected.
+const subscriberBuffer = 16
+
+// pingInterval is the time between pings to detect dead connections.
+const pingInterval = 30 * time.Second
+
+// A Feed pushes the state of the players to WebSocket subscribers.
+//
+// A single poll loop ([Feed.Run]) takes snapshots with
+// [rcon.Client.TakeSnapshot] and derives events with [rcon.DiffSnapshots],
+// no matter how many subscribers there are. While nobody is subscribed,
+// the server is not polled.
+//
+// Every message is a JSON object with a "type":
+//
+//	{"type": "snapshot", "time": ..., "serverTime": ..., "connected": 12, "players": [...]}
+//	{"type": "event", "event": "PlayerJoined", "time": ..., "player": {...}, "previous": {...}}
+//	{"type": "error", "time": ..., "error": "..."}
+//
+// The players have the same format as in GET /playerdata. A new subscriber
+// receives the latest snapshot right away.
+//
+// Subscribers can limit the players they receive with query parameters:
+//
+//	player=<id>                    Only this player; may be repeated
+//	class=<class>                  Only players of this class; may be repeated
+//	bbox=<minX>,<minY>,<maxX>,<maxY>  Only players within this area of the map
+//
+// A player must match every given kind of filter. Events match if the
+// player matches either before or after the change. Errors are sent to
+// every subscriber.
+type Feed struct {
+	client   rcon.Commander
+	interval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	latest      *rcon.Snapshot
+	stopped     bool
+
+	// wake is signalled when the first subscriber arrives.
+	wake chan struct{}
+}
+
+type subscriber struct {
+	filter   filter
+	messages chan []byte
+	// dropped is closed when the subscriber is disconnected by the feed.
+	dropped chan struct{}
+	reason  uint16
+}
+
+// NewFeed returns a feed that polls the server using client every
+// interval. Call [Feed.Run] to start polling.
+func NewFeed(client rcon.Commander, interval time.Duration) *Feed {
+	return &Feed{
+		client:      client,
+		interval:    interval,
+		subscribers: make(map[*subscriber]struct{}),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Run polls the server while there are subscribers, until ctx is done.
+// It then disconnects all subscribers and returns the error of ctx.
+func (f *Feed) Run(ctx context.Context) error {
+	defer f.stop()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	var prev *rcon.Snapshot
+	for {
+		if f.hasSubscribers() {
+			next, err := f.client.TakeSnapshot(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case err != nil:
+				f.broadcastError(err)
+			default:
+				f.broadcastSnapshot(prev, next)
+				prev = next
+			}
+		} else {
+			// Events are relative to the previous snapshot, which is
+			// outdated after a pause.
+			prev = nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-f.wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ServeHTTP accepts a WebSocket subscriber.
+func (f *Feed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sub, ok := f.subscribe(filter)
+	if !ok {
+		conn.writeClose(closeGoingAway, "feed stopped")
+		return
+	}
+	defer f.unsubscribe(sub)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.readLoop()
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case msg := <-sub.messages:
+			if err := conn.writeText(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		case <-sub.dropped:
+			conn.writeClose(sub.reason, "")
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (f *Feed) subscribe(filter filter) (*subscriber, bool) {
+	sub := &subscriber{
+		filter:   filter,
+		messages: make(chan []byte, subscriberBuffer),
+		dropped:  make(chan struct{}),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stopped {
+		return nil, false
+	}
+	if f.latest != nil {
+		sub.messages <- encodeSnapshot(f.latest, filter)
+	}
+	f.subscribers[sub] = struct{}{}
+
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+	return sub, true
+}
+
+func (f *Feed) unsubscribe(sub *subscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subscribers, sub)
+}
+
+func (f *Feed) hasSubscribers() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers) > 0
+}
+
+// stop disconnects all subscribers and rejects new ones.
+func (f *Feed) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	for sub := range f.subscribers {
+		f.drop(sub, closeGoingAway)
+	}
+}
+
+// drop disconnects a subscriber. The caller must hold f.mu.
+func (f *Feed) drop(sub *subscriber, reason uint16) {
+	delete(f.subscribers, sub)
+	sub.reason = reason
+	close(sub.dropped)
+}
+
+// send queues a message for every subscriber. Subscribers whose queue is
+// full are dropped.
+func (f *Feed) send(encode func(filter) []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subscribers {
+		msg := encode(sub.filter)
+		if msg == nil {
+			continue
+		}
+		select {
+		case sub.messages <- msg:
+		default:
+			f.drop(sub, closeTryAgainLater)
+		}
+	}
+}
+
+func (f *Feed) broadcastSnapshot(prev, next *rcon.Snapshot) {
+	f.mu.Lock()
+	f.latest = next
+	f.mu.Unlock()
+
+	if prev != nil {
+		for _, event := range rcon.DiffSnapshots(prev, next) {
+			f.send(func(filter filter) []byte {
+				if !filter.matchEvent(event) {
+					return nil
+				}
+				return encodeEvent(event)
+			})
+		}
+	}
+
+	f.send(func(filter filter) []byte {
+		return encodeSnapshot(next, filter)
+	})
+}
+
+func (f *Feed) broadcastError(err error) {
+	msg, _ := json.Marshal(struct {
+		Type  string    `json:"type"`
+		Time  time.Time `json:"time"`
+		Error string    `json:"error"`
+	}{"error", time.Now(), err.Error()})
+	f.send(func(filter) []byte { return msg })
+}
+
+func encodeSnapshot(snapshot *rcon.Snapshot, filter filter) []byte {
+	msg := struct {
+		Type       string       `json:"type"`
+		Time       time.Time    `json:"time"`
+		ServerTime *time.Time   `json:"serverTime,omitempty"`
+		Connected  int          `json:"connected"`
+		Players    []PlayerData `json:"players"`
+	}{
+		Type:      "snapshot",
+		Time:      snapshot.Time,
+		Connected: len(snapshot.Players),
+		Players:   []PlayerData{},
+	}
+	if !snapshot.ServerTime.IsZero() {
+		msg.ServerTime = &snapshot.ServerTime
+	}
+	for _, player := range snapshot.Spawned {
+		if filter.match(player) {
+			msg.Players = append(msg.Players, NewPlayerData(player))
+		}
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+func encodeEvent(event rcon.Event) []byte {
+	msg := struct {
+		Type     string      `json:"type"`
+		Event    string      `json:"event"`
+		Time     time.Time   `json:"time"`
+		Player   PlayerData  `json:"player"`
+		Previous *PlayerData `json:"previous,omitempty"`
+	}{
+		Type:   "event",
+		Event:  event.Type.String(),
+		Time:   event.Time,
+		Player: NewPlayerData(event.Player),
+	}
+	if event.Previous != nil {
+		previous := NewPlayerData(*event.Previous)
+		msg.Previous = &previous
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+// A filter selects the players that a subscriber receives. Empty fields
+// match every player.
+type filter struct {
+	players map[rcon.PlayerID]bool
+	classes map[rcon.DinoClass]bool
+	area    *area
+}
+
+// An area is a rectangle on the map.
+type area struct {
+	minX, minY, maxX, maxY float64
+}
+
+func parseFilter(query url.Values) (filter, error) {
+	var f filter
+
+	for _, s := range query["player"] {
+		id, err := rcon.ParsePlayerID(s)
+		if err != nil {
+			return f, err
+		}
+		if f.players == nil {
+			f.players = make(map[rcon.PlayerID]bool)
+		}
+		f.players[id] = true
+	}
+
+	for _, s := range query["class"] {
+		if !rcon.IsClass(s) {
+			return f, fmt.Errorf("unknown class %q", s)
+		}
+		if f.classes == nil {
+			f.classes = make(map[rcon.DinoClass]bool)
+		}
+		f.classes[rcon.DinoClass(s)] = true
+	}
+
+	if s := query.Get("bbox"); s != "" {
+		parts := strings.Split(s, ",")
+		if len(parts) != 4 {
+			return f, fmt.Errorf("bbox must be minX,minY,maxX,maxY")
+		}
+		var values [4]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return f, fmt.Errorf("bbox must be minX,minY,maxX,maxY")
+			}
+			values[i] = v
+		}
+		if values[0] > values[2] || values[1] > values[3] {
+			return f, fmt.Errorf("bbox minimum is larger than its maximum")
+		}
+		f.area = &area{values[0], values[1], values[2], values[3]}
+	}
+
+	return f, nil
+}
+
+func (f filter) match(player rcon.Player) bool {
+	if f.players != nil && !f.players[player.ID] {
+		return false
+	}
+	if f.classes != nil && !f.classes[player.DinoClass] {
+		return false
+	}
+	if f.area != nil {
+		x, y := player.Location.X, player.Location.Y
+		if x < f.area.minX || x > f.area.maxX || y < f.area.minY || y > f.area.maxY {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filter) matchEvent(event rcon.Event) bool {
+	return f.match(event.Player) || (event.Previous != nil && f.match(*event.Previous))
+}
@@ -0,0 +1,306 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+var (
+	feedAlice = rcon.Player{ID: "76561198000000001", Name: "Alice", DinoClass: rcon.Troodon, Growth: 75, Health: 100, Location: rcon.Location{X: 100, Y: 200}}
+	feedBob   = rcon.Player{ID: "76561198000000002", Name: "Bob", DinoClass: rcon.Stegosaurus, Growth: 10, Health: 50, Location: rcon.Location{X: -500, Y: 300}}
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"", false},
+		{"player=76561198000000001&player=76561198000000002", false},
+		{"class=Troodon", false},
+		{"bbox=-1,-2.5,3,4", false},
+		{"bbox=1,1,1,1", false},
+		{"player=alice", true},
+		{"class=Unicorn", true},
+		{"bbox=1,2,3", true},
+		{"bbox=1,2,3,x", true},
+		{"bbox=3,0,1,1", true},
+	}
+
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		if _, err := parseFilter(query); (err != nil) != tt.wantErr {
+			t.Errorf("parseFilter(%q) returned error %v, want error: %v", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []rcon.PlayerID
+	}{
+		{"", []rcon.PlayerID{feedAlice.ID, feedBob.ID}},
+		{"player=76561198000000002", []rcon.PlayerID{feedBob.ID}},
+		{"player=76561198000000003", nil},
+		{"class=Troodon&class=Stegosaurus", []rcon.PlayerID{feedAlice.ID, feedBob.ID}},
+		{"class=Stegosaurus", []rcon.PlayerID{feedBob.ID}},
+		{"bbox=0,0,100,200", []rcon.PlayerID{feedAlice.ID}},
+		{"bbox=-1000,0,0,1000", []rcon.PlayerID{feedBob.ID}},
+		{"class=Troodon&player=76561198000000002", nil},
+	}
+
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		filter, err := parseFilter(query)
+		if err != nil {
+			t.Fatalf("parseFilter(%q): %v", tt.query, err)
+		}
+		var got []rcon.PlayerID
+		for _, player := range []rcon.Player{feedAlice, feedBob} {
+			if filter.match(player) {
+				got = append(got, player.ID)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q matches %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFilterMatchEvent(t *testing.T) {
+	moved := feedAlice
+	moved.Location = rcon.Location{X: 5000, Y: 5000}
+	query, _ := url.ParseQuery("bbox=0,0,1000,1000")
+	filter, _ := parseFilter(query)
+
+	tests := []struct {
+		name  string
+		event rcon.Event
+		want  bool
+	}{
+		{"inside", rcon.Event{Type: rcon.PlayerSpawned, Player: feedAlice}, true},
+		{"outside", rcon.Event{Type: rcon.PlayerSpawned, Player: moved}, false},
+		{"left the area", rcon.Event{Type: rcon.PlayerRespawned, Player: moved, Previous: &feedAlice}, true},
+		{"entered the area", rcon.Event{Type: rcon.PlayerRespawned, Player: feedAlice, Previous: &moved}, true},
+	}
+
+	for _, tt := range tests {
+		if got := filter.matchEvent(tt.event); got != tt.want {
+			t.Errorf("%s: matchEvent = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// feedMessage is a message of the feed, as far as the tests need it.
+type feedMessage struct {
+	Type      string       `json:"type"`
+	Event     string       `json:"event"`
+	Connected int          `json:"connected"`
+	Players   []PlayerData `json:"players"`
+	Player    PlayerData   `json:"player"`
+}
+
+// subscribe connects to the feed of a gateway at url.
+func subscribe(t *testing.T, rawURL string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	u, _ := url.Parse(rawURL)
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, rawURL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	return conn, br
+}
+
+// readMessage reads the next text message sent by the feed.
+func readMessage(t *testing.T, br *bufio.Reader) feedMessage {
+	t.Helper()
+
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if opcode := header[0] & 0x0f; opcode != opText {
+		t.Fatalf("got opcode %#x, want a text frame", opcode)
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			t.Fatal(err)
+		}
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg feedMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("invalid message %s: %v", payload, err)
+	}
+	return msg
+}
+
+// startFeed serves g with a feed that polls every 50ms.
+func startFeed(t *testing.T, g *Gateway) *httptest.Server {
+	t.Helper()
+
+	feed := NewFeed(g.client, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go feed.Run(ctx)
+
+	g.HandleFeed(feed)
+	ts := httptest.NewServer(g)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"all", "", []string{"Alice", "Bob"}},
+		{"class", "class=Stegosaurus", []string{"Bob"}},
+		{"player", "player=76561198000000001", []string{"Alice"}},
+		{"bbox", "bbox=0,0,1000,1000", []string{"Alice"}},
+	}
+
+	server, g := newTestGateway(t)
+	server.Update(func(world *rcontest.World) {
+		world.AddPlayer(feedAlice)
+		world.AddPlayer(feedBob)
+	})
+	ts := startFeed(t, g)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, br := subscribe(t, ts.URL+"/feed?api_key="+testKey+"&"+tt.query)
+			msg := readMessage(t, br)
+			if msg.Type != "snapshot" || msg.Connected != 2 {
+				t.Fatalf("got %s message with %d players, want a snapshot with 2", msg.Type, msg.Connected)
+			}
+			var names []string
+			for _, player := range msg.Players {
+				names = append(names, player.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("players = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		change func(world *rcontest.World)
+		want   []string
+	}{
+		{
+			name:   "left",
+			change: func(world *rcontest.World) { world.RemovePlayer(feedBob.ID) },
+			want:   []string{"PlayerLeft Bob"},
+		},
+		{
+			name:   "filtered",
+			query:  "class=Troodon",
+			change: func(world *rcontest.World) { world.RemovePlayer(feedBob.ID) },
+			want:   nil,
+		},
+		{
+			name:   "joined",
+			change: func(world *rcontest.World) { world.AddPlayer(rcon.Player{ID: "76561198000000003", Name: "Carol"}) },
+			want:   []string{"PlayerJoined Carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, g := newTestGateway(t)
+			server.Update(func(world *rcontest.World) {
+				world.AddPlayer(feedAlice)
+				world.AddPlayer(feedBob)
+			})
+			ts := startFeed(t, g)
+
+			_, br := subscribe(t, ts.URL+"/feed?api_key="+testKey+"&"+tt.query)
+			if msg := readMessage(t, br); msg.Type != "snapshot" {
+				t.Fatalf("got %s message, want a snapshot first", msg.Type)
+			}
+			server.Update(tt.change)
+
+			// The events of a poll are sent before its snapshot, which is
+			// the first one with a different number of players.
+			var got []string
+			for {
+				msg := readMessage(t, br)
+				if msg.Type == "snapshot" {
+					if msg.Connected == 2 {
+						continue
+					}
+					break
+				}
+				got = append(got, msg.Event+" "+msg.Player.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package httpgateway exposes the methods of a [rcon.Client] as a JSON
+// API over HTTP, for programs that cannot speak RCON themselves.
+//
+// All requests share the connection of the client, which sends one
+// command at a time. Every request must carry one of the API keys of the
+// gateway, either as "Authorization: Bearer <key>" or as "X-API-Key:
+// <key>". WebSocket connections may also pass it as the query parameter
+// api_key.
+//
+// The endpoints are:
+//
+//	GET    /players                    List of connected players
+//	GET    /playerdata                 Location, class and stats of every player
+//	GET    /details                    Server details
+//	POST   /announce                   {"message": "..."}
+//	POST   /players/{id}/message       {"message": "..."}
+//	POST   /players/{id}/kick          {"reason": "..."}
+//	POST   /players/{id}/ban           {"reason": "...", "minutes": 60}, 0 minutes bans permanently
+//	PUT    /whitelist                  {"enabled": true}
+//	PUT    /whitelist/{id}             Add a player to the whitelist
+//	DELETE /whitelist/{id}             Remove a player from the whitelist
+//	PUT    /globalchat                 {"enabled": true}
+//	PUT    /humans                     {"enabled": true}
+//	PUT    /ai                         {"enabled": true}
+//	PUT    /ai/density                 {"density": 0.5}
+//	PUT    /ai/disabled                {"classes": ["Boar", "Deer"]}
+//	PUT    /playables                  {"classes": ["Troodon", "Stegosaurus"]}
+//	POST   /save                       Save the map
+//	POST   /wipecorpses                Remove all corpses
+//	GET    /feed                       WebSocket feed of players, if enabled with [Gateway.HandleFeed]
+//
+// Errors are returned as {"error": "..."} with a matching status code:
+// 400 for invalid requests, 401 for a missing or wrong API key, 422 if the
+// server rejected the command, 502 if the server could not be reached or
+// sent a malformed response and 504 if it did not answer in time.
+package httpgateway
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// DefaultTimeout is the time that a request may take if Gateway.Timeout
+// is not set.
+const DefaultTimeout = 10 * time.Second
+
+// maxBodySize limits the size of request bodies.
+const maxBodySize = 64 << 10
+
+// A Gateway is an [http.Handler] that serves the JSON API for one server.
+type Gateway struct {
+	client rcon.Commander
+	keys   [][]byte
+	mux    *http.ServeMux
+
+	// Timeout limits the time that a request may spend talking to the
+	// server, including waiting for other requests. The default is
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+// New returns a gateway to client that accepts the given API keys. If no
+// keys are given, every request is rejected.
+func New(client rcon.Commander, apiKeys ...string) *Gateway {
+	g := &Gateway{client: client, mux: http.NewServeMux()}
+	for _, key := range apiKeys {
+		if key != "" {
+			g.keys = append(g.keys, []byte(key))
+		}
+	}
+
+	g.handle("GET /players", g.getPlayers)
+	g.handle("GET /playerdata", g.getPlayerData)
+	g.handle("GET /details", g.getDetails)
+	g.handle("POST /announce", g.announce)
+	g.handle("POST /players/{id}/message", g.sendMessage)
+	g.handle("POST /players/{id}/kick", g.kick)
+	g.handle("POST /players/{id}/ban", g.ban)
+	g.handle("PUT /whitelist", g.setter(rcon.Commander.SetWhitelistContext))
+	g.handle("PUT /whitelist/{id}", g.addWhitelist)
+	g.handle("DELETE /whitelist/{id}", g.removeWhitelist)
+	g.handle("PUT /globalchat", g.setter(rcon.Commander.SetGlobalChatContext))
+	g.handle("PUT /humans", g.setter(rcon.Commander.SetHumansContext))
+	g.handle("PUT /ai", g.setter(rcon.Commander.SetAIContext))
+	g.handle("PUT /ai/density", g.setAIDensity)
+	g.handle("PUT /ai/disabled", g.disableAIClasses)
+	g.handle("PUT /playables", g.updatePlayables)
+	g.handle("POST /save", g.save)
+	g.handle("POST /wipecorpses", g.wipeCorpses)
+
+	return g
+}
+
+// ServeHTTP checks the API key and handles the request.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	g.mux.ServeHTTP(w, r)
+}
+
+// handle registers a handler that talks to the server. Its context is
+// limited by the timeout of the gateway.
+func (g *Gateway) handle(pattern string, handler http.HandlerFunc) {
+	g.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), cmp.Or(g.Timeout, DefaultTimeout))
+		defer cancel()
+		handler(w, r.WithContext(ctx))
+	})
+}
+
+// HandleFeed serves feed at GET /feed. See [Feed] for details.
+func (g *Gateway) HandleFeed(feed *Feed) {
+	g.mux.Handle("GET /feed", feed)
+}
+
+func (g *Gateway) authorized(r *http.Request) bool {
+	key := r.Header.Get("X-API-Key")
+	if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		key = auth
+	}
+	// Browsers cannot set headers on WebSocket connections.
+	if key == "" && isWebSocketUpgrade(r) {
+		key = r.URL.Query().Get("api_key")
+	}
+	if key == "" {
+		return false
+	}
+
+	ok := false
+	for _, k := range g.keys {
+		// Compare against every key, so the time does not depend on
+		// which key matched.
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
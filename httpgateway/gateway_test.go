@@ -0,0 +1,257 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+const testKey = "secret"
+
+// newTestGateway starts a fake server and returns a gateway to it.
+func newTestGateway(t *testing.T) (*rcontest.Server, *Gateway) {
+	t.Helper()
+
+	server := rcontest.NewServer("password")
+	t.Cleanup(server.Close)
+
+	client, err := rcon.Connect(server.Addr)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+
+	return server, New(client, testKey)
+}
+
+func serve(g *Gateway, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testKey)
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBan(t *testing.T) {
+	const id = "76561198000000001"
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantMinutes int
+		wantError   string
+	}{
+		{"minutes", `{"reason": "griefing", "minutes": 60}`, http.StatusNoContent, 60, ""},
+		{"permanent", `{"reason": "griefing", "minutes": 0}`, http.StatusNoContent, 0, ""},
+		{"longest", `{"reason": "griefing", "minutes": 153722867}`, http.StatusNoContent, 153722867, ""},
+		{"too long", `{"reason": "griefing", "minutes": 153722868}`, http.StatusBadRequest, 0, "to 153722867"},
+		{"overflow", `{"reason": "griefing", "minutes": 400000000}`, http.StatusBadRequest, 0, "to 153722867"},
+		{"negative", `{"reason": "griefing", "minutes": -1}`, http.StatusBadRequest, 0, "to 153722867"},
+		{"missing", `{"reason": "griefing"}`, http.StatusBadRequest, 0, "to 153722867"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, g := newTestGateway(t)
+
+			rec := serve(g, "POST", "/players/"+id+"/ban", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantError) {
+				t.Errorf("body %q does not contain %q", rec.Body, tt.wantError)
+			}
+
+			var bans []rcontest.Ban
+			server.Update(func(world *rcontest.World) { bans = world.Bans })
+			if tt.wantStatus != http.StatusNoContent {
+				if len(bans) != 0 {
+					t.Errorf("server got bans %v, want none", bans)
+				}
+				return
+			}
+			if len(bans) != 1 || bans[0].Minutes != tt.wantMinutes {
+				t.Errorf("server got bans %v, want one of %d minutes", bans, tt.wantMinutes)
+			}
+		})
+	}
+}
+
+func TestMaxBanMinutes(t *testing.T) {
+	if d := time.Duration(maxBanMinutes) * time.Minute; d < 0 || int64(d/time.Minute) != maxBanMinutes {
+		t.Errorf("%d minutes overflow a time.Duration", maxBanMinutes)
+	}
+}
+
+func TestGatewayWithPool(t *testing.T) {
+	server := rcontest.NewServer("password")
+	defer server.Close()
+	server.Update(func(world *rcontest.World) {
+		world.Players = []rcon.Player{{ID: "76561198000000001", Name: "Alice"}}
+	})
+
+	pool, err := rcon.NewPool(context.Background(), server.Addr, "password", 2)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	defer pool.Close()
+
+	rec := serve(New(pool, testKey), "GET", "/players", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Alice") {
+		t.Errorf("got status %d and body %s, want the player list", rec.Code, rec.Body)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		query  string
+		// upgrade makes the request a WebSocket handshake.
+		upgrade    bool
+		wantStatus int
+	}{
+		{"no key", nil, "", false, http.StatusUnauthorized},
+		{"bearer", map[string]string{"Authorization": "Bearer secret"}, "", false, http.StatusOK},
+		{"second key", map[string]string{"Authorization": "Bearer other"}, "", false, http.StatusOK},
+		{"wrong bearer", map[string]string{"Authorization": "Bearer wrong"}, "", false, http.StatusUnauthorized},
+		{"prefix of key", map[string]string{"Authorization": "Bearer secre"}, "", false, http.StatusUnauthorized},
+		{"basic", map[string]string{"Authorization": "Basic secret"}, "", false, http.StatusUnauthorized},
+		{"empty bearer", map[string]string{"Authorization": "Bearer "}, "", false, http.StatusUnauthorized},
+		{"api key header", map[string]string{"X-API-Key": "secret"}, "", false, http.StatusOK},
+		{"wrong api key header", map[string]string{"X-API-Key": "wrong"}, "", false, http.StatusUnauthorized},
+		{"bearer wins", map[string]string{"Authorization": "Bearer wrong", "X-API-Key": "secret"}, "", false, http.StatusUnauthorized},
+		{"query without upgrade", nil, "api_key=secret", false, http.StatusUnauthorized},
+		{"query with upgrade", nil, "api_key=secret", true, http.StatusOK},
+		{"wrong query with upgrade", nil, "api_key=wrong", true, http.StatusUnauthorized},
+		{"header wins over query", map[string]string{"X-API-Key": "wrong"}, "api_key=secret", true, http.StatusUnauthorized},
+	}
+
+	_, g := newTestGateway(t)
+	g = New(g.client, testKey, "other", "")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/players?"+tt.query, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			if tt.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+			rec := httptest.NewRecorder()
+			g.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want %q", rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestNoKeys(t *testing.T) {
+	_, g := newTestGateway(t)
+	g = New(g.client, "")
+
+	for _, header := range []string{"Bearer ", "Bearer"} {
+		req := httptest.NewRequest("GET", "/players", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want %d", header, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestClassLists(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		want       []string
+	}{
+		{"playables", "/playables", `{"classes": ["Troodon", "Stegosaurus"]}`, http.StatusNoContent, []string{"Troodon", "Stegosaurus"}},
+		{"unknown playable", "/playables", `{"classes": ["Troodon", "Unicorn"]}`, http.StatusBadRequest, nil},
+		{"AI class as playable", "/playables", `{"classes": ["Boar"]}`, http.StatusBadRequest, nil},
+		{"no playables", "/playables", `{"classes": []}`, http.StatusBadRequest, nil},
+		{"separator in playable", "/playables", `{"classes": ["Troodon,Stegosaurus"]}`, http.StatusBadRequest, nil},
+		{"disabled AI", "/ai/disabled", `{"classes": ["Boar", "Deer"]}`, http.StatusNoContent, []string{"Boar", "Deer"}},
+		{"no disabled AI", "/ai/disabled", `{"classes": []}`, http.StatusNoContent, []string{}},
+		{"unknown AI class", "/ai/disabled", `{"classes": ["Boar", "Dragon"]}`, http.StatusBadRequest, nil},
+		{"playable as AI class", "/ai/disabled", `{"classes": ["Troodon"]}`, http.StatusBadRequest, nil},
+		{"lower case AI class", "/ai/disabled", `{"classes": ["boar"]}`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, g := newTestGateway(t)
+			var before []string
+			server.Update(func(world *rcontest.World) { before = classNames(world, tt.path) })
+
+			rec := serve(g, "PUT", tt.path, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var got []string
+			server.Update(func(world *rcontest.World) { got = classNames(world, tt.path) })
+			want := tt.want
+			if tt.wantStatus != http.StatusNoContent {
+				// Nothing is sent to the server.
+				want = before
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("server has classes %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// classNames returns the classes of world that path changes.
+func classNames(world *rcontest.World, path string) []string {
+	names := []string{}
+	if path == "/playables" {
+		for _, class := range world.Playables {
+			names = append(names, string(class))
+		}
+	} else {
+		for _, class := range world.DisabledAIClasses {
+			names = append(names, string(class))
+		}
+	}
+	return names
+}
@@ -0,0 +1,266 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+func (g *Gateway) getPlayers(w http.ResponseWriter, r *http.Request) {
+	players, err := g.client.GetPlayerListContext(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	res := struct {
+		Players []Player `json:"players"`
+	}{make([]Player, len(players))}
+	for i, player := range players {
+		res.Players[i] = Player{ID: player.ID, Name: player.Name}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (g *Gateway) getPlayerData(w http.ResponseWriter, r *http.Request) {
+	players, skipped, err := g.client.GetPlayerDataLenientContext(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+
+	res := struct {
+		Players []PlayerData `json:"players"`
+		Errors  []string     `json:"errors,omitempty"`
+	}{Players: make([]PlayerData, len(players))}
+	for i, player := range players {
+		res.Players[i] = NewPlayerData(player)
+	}
+	for _, err := range skipped {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (g *Gateway) getDetails(w http.ResponseWriter, r *http.Request) {
+	details, err := g.client.GetServerDetailsContext(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewServerDetails(details))
+}
+
+func (g *Gateway) announce(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message must not be empty")
+		return
+	}
+	g.done(w, g.client.AnnounceContext(r.Context(), req.Message))
+}
+
+func (g *Gateway) sendMessage(w http.ResponseWriter, r *http.Request) {
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Message string `json:"message"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message must not be empty")
+		return
+	}
+	g.done(w, g.client.SendDirectMessageContext(r.Context(), id, req.Message))
+}
+
+func (g *Gateway) kick(w http.ResponseWriter, r *http.Request) {
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	g.done(w, g.client.KickPlayerContext(r.Context(), id, req.Reason))
+}
+
+// maxBanMinutes is the longest ban that fits into a [time.Duration].
+const maxBanMinutes = math.MaxInt64 / int64(time.Minute)
+
+func (g *Gateway) ban(w http.ResponseWriter, r *http.Request) {
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason  string `json:"reason"`
+		Minutes *int64 `json:"minutes"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Minutes == nil || *req.Minutes < 0 || *req.Minutes > maxBanMinutes {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("minutes must be a number from 0 (permanent) to %d", maxBanMinutes))
+		return
+	}
+	duration := time.Duration(*req.Minutes) * time.Minute
+	g.done(w, g.client.BanPlayerContext(r.Context(), id, req.Reason, duration))
+}
+
+// setter returns a handler for one of the Set methods of the client.
+func (g *Gateway) setter(set func(rcon.Commander, context.Context, bool) (bool, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Enabled == nil {
+			writeError(w, http.StatusBadRequest, "enabled must be true or false")
+			return
+		}
+
+		changed, err := set(g.client, r.Context(), *req.Enabled)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Enabled bool `json:"enabled"`
+			Changed bool `json:"changed"`
+		}{*req.Enabled, changed})
+	}
+}
+
+func (g *Gateway) addWhitelist(w http.ResponseWriter, r *http.Request) {
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	g.done(w, g.client.AddWhitelistIDContext(r.Context(), id))
+}
+
+func (g *Gateway) removeWhitelist(w http.ResponseWriter, r *http.Request) {
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+	g.done(w, g.client.RemoveWhitelistIDContext(r.Context(), id))
+}
+
+func (g *Gateway) setAIDensity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Density *float32 `json:"density"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Density == nil || *req.Density < 0 {
+		writeError(w, http.StatusBadRequest, "density must be a number of at least 0")
+		return
+	}
+	g.done(w, g.client.SetAIDensityContext(r.Context(), *req.Density))
+}
+
+func (g *Gateway) disableAIClasses(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Classes []string `json:"classes"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	classes := make([]rcon.AIClass, len(req.Classes))
+	for i, class := range req.Classes {
+		if !rcon.IsAIClass(class) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown AI class %q", class))
+			return
+		}
+		classes[i] = rcon.AIClass(class)
+	}
+	g.done(w, g.client.DisableAIClassesContext(r.Context(), classes))
+}
+
+func (g *Gateway) updatePlayables(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Classes []string `json:"classes"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.Classes) == 0 {
+		writeError(w, http.StatusBadRequest, "classes must not be empty")
+		return
+	}
+	classes := make([]rcon.DinoClass, len(req.Classes))
+	for i, class := range req.Classes {
+		if !rcon.IsClass(class) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown class %q", class))
+			return
+		}
+		classes[i] = rcon.DinoClass(class)
+	}
+	g.done(w, g.client.UpdatePlayablesContext(r.Context(), classes))
+}
+
+func (g *Gateway) save(w http.ResponseWriter, r *http.Request) {
+	g.done(w, g.client.SaveContext(r.Context()))
+}
+
+func (g *Gateway) wipeCorpses(w http.ResponseWriter, r *http.Request) {
+	g.done(w, g.client.WipeCorpsesContext(r.Context()))
+}
+
+// done writes the response of a command that returns nothing.
+func (g *Gateway) done(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// playerID returns the player ID from the path of r. If it is invalid, it
+// writes an error response and returns false.
+func playerID(w http.ResponseWriter, r *http.Request) (rcon.PlayerID, bool) {
+	id, err := rcon.ParsePlayerID(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return id, true
+}
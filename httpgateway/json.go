@@ -0,0 +1,169 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// The types in this file define the JSON representation of the responses.
+// They are separate from the types of the rcon package, so that the API
+// does not change when those do.
+
+// Player is a player in the response of GET /players.
+type Player struct {
+	ID   rcon.PlayerID `json:"id"`
+	Name string        `json:"name"`
+}
+
+// PlayerData is a player in the response of GET /playerdata.
+type PlayerData struct {
+	ID       rcon.PlayerID `json:"id"`
+	Name     string        `json:"name"`
+	Class    string        `json:"class"`
+	Growth   int8          `json:"growth"`
+	Health   int8          `json:"health"`
+	Stamina  int8          `json:"stamina"`
+	Hunger   int8          `json:"hunger"`
+	Thirst   int8          `json:"thirst"`
+	Location Location      `json:"location"`
+}
+
+// Location is the position of a player on the map.
+type Location struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// ServerDetails is the response of GET /details.
+type ServerDetails struct {
+	Timestamp                      *time.Time        `json:"timestamp,omitempty"`
+	Name                           string            `json:"name"`
+	Map                            string            `json:"map"`
+	MaxPlayers                     int               `json:"maxPlayers"`
+	CurrentPlayers                 int               `json:"currentPlayers"`
+	EnableMutations                bool              `json:"enableMutations"`
+	EnableHumans                   bool              `json:"enableHumans"`
+	HasPassword                    bool              `json:"hasPassword"`
+	QueueEnabled                   bool              `json:"queueEnabled"`
+	Whitelist                      bool              `json:"whitelist"`
+	SpawnAI                        bool              `json:"spawnAI"`
+	AllowRecordingGameplay         bool              `json:"allowRecordingGameplay"`
+	UseRegionSpawning              bool              `json:"useRegionSpawning"`
+	UseRegionSpawnCooldown         bool              `json:"useRegionSpawnCooldown"`
+	RegionSpawnCooldownTimeSeconds int               `json:"regionSpawnCooldownTimeSeconds"`
+	DayLengthMinutes               int               `json:"dayLengthMinutes"`
+	NightLengthMinutes             int               `json:"nightLengthMinutes"`
+	EnableGlobalChat               bool              `json:"enableGlobalChat"`
+	Extra                          map[string]string `json:"extra,omitempty"`
+}
+
+// NewPlayerData converts a player to its JSON representation.
+func NewPlayerData(player rcon.Player) PlayerData {
+	return PlayerData{
+		ID:       player.ID,
+		Name:     player.Name,
+		Class:    player.DinoClass.Name(),
+		Growth:   player.Growth,
+		Health:   player.Health,
+		Stamina:  player.Stamina,
+		Hunger:   player.Hunger,
+		Thirst:   player.Thirst,
+		Location: Location(player.Location),
+	}
+}
+
+// NewServerDetails converts server details to their JSON representation.
+func NewServerDetails(details *rcon.ServerDetails) ServerDetails {
+	res := ServerDetails{
+		Name:                           details.Name,
+		Map:                            details.Map,
+		MaxPlayers:                     details.MaxPlayers,
+		CurrentPlayers:                 details.CurrentPlayers,
+		EnableMutations:                details.EnableMutations,
+		EnableHumans:                   details.EnableHumans,
+		HasPassword:                    details.HasPassword,
+		QueueEnabled:                   details.QueueEnabled,
+		Whitelist:                      details.Whitelist,
+		SpawnAI:                        details.SpawnAI,
+		AllowRecordingGameplay:         details.AllowRecordingGameplay,
+		UseRegionSpawning:              details.UseRegionSpawning,
+		UseRegionSpawnCooldown:         details.UseRegionSpawnCooldown,
+		RegionSpawnCooldownTimeSeconds: details.RegionSpawnCooldownTimeSeconds,
+		DayLengthMinutes:               details.DayLengthMinutes,
+		NightLengthMinutes:             details.NightLengthMinutes,
+		EnableGlobalChat:               details.EnableGlobalChat,
+		Extra:                          details.Extra,
+	}
+	if !details.Timestamp.IsZero() {
+		res.Timestamp = &details.Timestamp
+	}
+	return res
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{message})
+}
+
+// writeClientError writes an error returned by the client with the status
+// code that matches its cause.
+func writeClientError(w http.ResponseWriter, err error) {
+	var argErr *rcon.ArgumentError
+	status := http.StatusBadGateway
+	switch {
+	case errors.As(err, &argErr), errors.Is(err, rcon.ErrInvalidPlayerID):
+		status = http.StatusBadRequest
+	case errors.Is(err, rcon.ErrCommandFailed):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, rcon.ErrUnexpectedState):
+		status = http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		// The caller is gone, nobody reads the response anyway.
+		status = http.StatusServiceUnavailable
+	}
+	writeError(w, status, err.Error())
+}
+
+// decodeBody decodes the JSON body of r into v. If that fails, it writes
+// an error response and returns false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
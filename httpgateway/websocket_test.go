@@ -0,0 +1,354 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package httpgateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+		accept string
+	}{
+		{
+			// The example from RFC 6455, section 1.3.
+			name: "valid",
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Version": "13",
+			},
+			status: http.StatusSwitchingProtocols,
+			accept: "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+		},
+		{
+			name: "connection token list",
+			header: map[string]string{
+				"Connection":            "keep-alive, Upgrade",
+				"Upgrade":               "WebSocket",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Version": "13",
+			},
+			status: http.StatusSwitchingProtocols,
+			accept: "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=",
+		},
+		{
+			name: "no upgrade",
+			header: map[string]string{
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Version": "13",
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "no key",
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Version": "13",
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "short key",
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Key":     "c2hvcnQ=",
+				"Sec-WebSocket-Version": "13",
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "old version",
+			header: map[string]string{
+				"Connection":            "Upgrade",
+				"Upgrade":               "websocket",
+				"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+				"Sec-WebSocket-Version": "8",
+			},
+			status: http.StatusUpgradeRequired,
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrade(w, r); err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+
+			// http.Client does not hand out the connection after a 101,
+			// so the request is written by hand.
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(time.Second))
+			if err := req.Write(conn); err != nil {
+				t.Fatal(err)
+			}
+			res, err := http.ReadResponse(bufio.NewReader(conn), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.status)
+			}
+			if got := res.Header.Get("Sec-WebSocket-Accept"); got != tt.accept {
+				t.Errorf("Sec-WebSocket-Accept = %q, want %q", got, tt.accept)
+			}
+			if tt.status == http.StatusUpgradeRequired {
+				if got := res.Header.Get("Sec-WebSocket-Version"); got != "13" {
+					t.Errorf("Sec-WebSocket-Version = %q, want %q", got, "13")
+				}
+			}
+		})
+	}
+}
+
+// pipe returns a server connection and the client end of it.
+func pipe(t *testing.T) (*wsConn, net.Conn) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return &wsConn{conn: server, br: bufio.NewReader(server)}, client
+}
+
+// clientFrame returns a masked frame, as clients send them.
+func clientFrame(opcode byte, payload []byte) []byte {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+func TestWriteFrame(t *testing.T) {
+	tests := []struct {
+		size   int
+		header []byte
+	}{
+		{0, []byte{0x81, 0}},
+		{125, []byte{0x81, 125}},
+		{126, []byte{0x81, 126, 0, 126}},
+		{65535, []byte{0x81, 126, 0xff, 0xff}},
+		{65536, []byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		conn, client := pipe(t)
+		payload := bytes.Repeat([]byte{'x'}, tt.size)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- conn.writeText(payload)
+			conn.Close()
+		}()
+
+		client.SetReadDeadline(time.Now().Add(time.Second))
+		got, err := io.ReadAll(client)
+		if err != nil {
+			t.Fatalf("size %d: %v", tt.size, err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("size %d: writeText: %v", tt.size, err)
+		}
+		if len(got) != len(tt.header)+tt.size {
+			t.Fatalf("size %d: got %d bytes, want %d", tt.size, len(got), len(tt.header)+tt.size)
+		}
+		if header := got[:len(tt.header)]; !bytes.Equal(header, tt.header) {
+			t.Errorf("size %d: header = %x, want %x", tt.size, header, tt.header)
+		}
+		if !bytes.Equal(got[len(tt.header):], payload) {
+			t.Errorf("size %d: payload differs", tt.size)
+		}
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   []byte
+		opcode  byte
+		payload []byte
+		err     error
+	}{
+		{
+			name:    "ping",
+			frame:   clientFrame(opPing, []byte("hello")),
+			opcode:  opPing,
+			payload: []byte("hello"),
+		},
+		{
+			name:    "empty",
+			frame:   clientFrame(opClose, nil),
+			opcode:  opClose,
+			payload: []byte{},
+		},
+		{
+			name:    "16 bit length",
+			frame:   clientFrame(opText, bytes.Repeat([]byte{'x'}, 200)),
+			opcode:  opText,
+			payload: bytes.Repeat([]byte{'x'}, 200),
+		},
+		{
+			name:    "largest",
+			frame:   clientFrame(opText, bytes.Repeat([]byte{'x'}, maxClientFrame)),
+			opcode:  opText,
+			payload: bytes.Repeat([]byte{'x'}, maxClientFrame),
+		},
+		{
+			name:  "too large",
+			frame: clientFrame(opText, bytes.Repeat([]byte{'x'}, maxClientFrame+1))[:8],
+			err:   errFrameTooLarge,
+		},
+		{
+			name:  "64 bit length",
+			frame: []byte{0x81, 0x80 | 127, 0, 0, 1, 0, 0, 0, 0, 0},
+			err:   errFrameTooLarge,
+		},
+		{
+			name:  "unmasked",
+			frame: []byte{0x89, 5, 'h', 'e', 'l', 'l', 'o'},
+			err:   errUnmaskedFrame,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := pipe(t)
+			go client.Write(tt.frame)
+
+			opcode, payload, err := conn.readFrame()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("readFrame returned error %v, want %v", err, tt.err)
+			}
+			if opcode != tt.opcode || !bytes.Equal(payload, tt.payload) {
+				t.Errorf("readFrame = %#x, %q, want %#x, %q", opcode, payload, tt.opcode, tt.payload)
+			}
+		})
+	}
+}
+
+func TestReadLoop(t *testing.T) {
+	tests := []struct {
+		name string
+		// frames are sent by the client in this order.
+		frames [][]byte
+		// want are the frames that the server answers with.
+		want [][]byte
+		err  error
+	}{
+		{
+			name:   "ping",
+			frames: [][]byte{clientFrame(opPing, []byte("hi")), clientFrame(opClose, []byte{0x03, 0xe8})},
+			want:   [][]byte{{0x8a, 2, 'h', 'i'}, {0x88, 2, 0x03, 0xe8}},
+		},
+		{
+			name:   "text is ignored",
+			frames: [][]byte{clientFrame(opText, []byte("hi")), clientFrame(opClose, []byte{0x03, 0xe8})},
+			want:   [][]byte{{0x88, 2, 0x03, 0xe8}},
+		},
+		{
+			name:   "close echoes the code",
+			frames: [][]byte{clientFrame(opClose, []byte{0x03, 0xe8, 'b', 'y', 'e'})},
+			want:   [][]byte{{0x88, 2, 0x03, 0xe8}},
+		},
+		{
+			name:   "unmasked",
+			frames: [][]byte{{0x89, 0}},
+			want:   [][]byte{{0x88, 2, 0x03, 0xea}},
+			err:    errUnmaskedFrame,
+		},
+		{
+			name:   "too large",
+			frames: [][]byte{clientFrame(opText, make([]byte, maxClientFrame+1))[:8]},
+			want:   [][]byte{{0x88, 2, 0x03, 0xf1}},
+			err:    errFrameTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := pipe(t)
+			errc := make(chan error, 1)
+			go func() { errc <- conn.readLoop() }()
+
+			// The pipe is unbuffered, so the frames are written while the
+			// answers are read.
+			client.SetDeadline(time.Now().Add(time.Second))
+			go func() {
+				for _, frame := range tt.frames {
+					if _, err := client.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+			for _, want := range tt.want {
+				got := make([]byte, len(want))
+				if _, err := io.ReadFull(client, got); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("got frame %x, want %x", got, want)
+				}
+			}
+			if err := <-errc; !errors.Is(err, tt.err) {
+				t.Errorf("readLoop returned %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
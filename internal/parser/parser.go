@@ -23,13 +23,52 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Error describes where and why parsing failed.
+type Error struct {
+	Expected string // What the parser expected, e.g. `"="` or "digits"
+	Rest     string // The input at the position where parsing failed
+	Field    string // The field that was being parsed, if known
+	Err      error  // The underlying error, if any
+}
+
+func (e *Error) Error() string {
+	s := "expected " + e.Expected
+	if e.Field != "" {
+		s = e.Field + ": " + s
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Expected returns an error for input rest that does not start with
+// what was expected.
+func Expected(expected, rest string) *Error {
+	return &Error{Expected: expected, Rest: rest}
+}
+
+// InField sets the field of err, if it is an *Error without a field.
+func InField(field string, err error) error {
+	if e, ok := err.(*Error); ok && e.Field == "" {
+		e.Field = field
+	}
+	return err
+}
+
 func Tag(msg, substr string) (string, error) {
-	if msg[:len(substr)] == substr {
+	if strings.HasPrefix(msg, substr) {
 		return msg[len(substr):], nil
 	} else {
-		return "", fmt.Errorf("expected \"%s\"", substr)
+		return "", Expected(fmt.Sprintf("%q", substr), msg)
 	}
 }
 
@@ -41,31 +80,33 @@ func ParseDigits(msg string) (string, string, error) {
 	if pos > 0 {
 		return msg[:pos], msg[pos:], nil
 	} else {
-		return "", "", fmt.Errorf("expected digits")
+		return "", "", Expected("digits", msg)
 	}
 }
 
 func ParseInt64(msg string) (int64, string, error) {
-	digits, msg, err := ParseDigits(msg)
+	digits, rest, err := ParseDigits(msg)
 	if err != nil {
 		return 0, "", err
 	}
 	num, err := strconv.ParseInt(digits, 10, 64)
 	if err != nil {
-		return 0, "", err
+		return 0, "", &Error{Expected: "integer", Rest: msg, Err: err}
 	}
+	msg = rest
 	return num, msg, nil
 }
 
 func ParseInt(msg string) (int, string, error) {
-	digits, msg, err := ParseDigits(msg)
+	digits, rest, err := ParseDigits(msg)
 	if err != nil {
 		return 0, "", err
 	}
 	num, err := strconv.Atoi(digits)
 	if err != nil {
-		return 0, "", err
+		return 0, "", &Error{Expected: "integer", Rest: msg, Err: err}
 	}
+	msg = rest
 	return num, msg, nil
 }
 
@@ -89,11 +130,11 @@ func ParseFloat64(msg string) (float64, string, error) {
 	if pos > 0 && msg[pos-1] != '-' {
 		f, err := strconv.ParseFloat(msg[:pos], 64)
 		if err != nil {
-			return 0, "", err
+			return 0, "", &Error{Expected: "float", Rest: msg, Err: err}
 		}
 		return f, msg[pos:], nil
 	} else {
-		return 0, "", fmt.Errorf("expected float at %s", msg[:10])
+		return 0, "", Expected("float", msg)
 	}
 }
 
@@ -118,61 +159,41 @@ func IsAsciiLetter(b byte) bool {
 	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
 }
 
-func ParseTimestamp(msg string) (string, string, error) {
-	year, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
-	}
-
-	msg, err = Tag(msg, ".")
-	if err != nil {
-		return "", "", err
-	}
-
-	month, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
-	}
-
-	msg, err = Tag(msg, ".")
-	if err != nil {
-		return "", "", err
-	}
-
-	day, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
-	}
-
-	msg, err = Tag(msg, "-")
-	if err != nil {
-		return "", "", err
-	}
-
-	hour, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
-	}
+// ParseTimestamp parses a timestamp of the form YYYY.MM.DD-HH.MM.SS in the
+// given location.
+func ParseTimestamp(msg string, loc *time.Location) (time.Time, string, error) {
+	start := msg
+	var fields [6]int
 
-	msg, err = Tag(msg, ".")
-	if err != nil {
-		return "", "", err
-	}
+	for i, sep := range []string{".", ".", "-", ".", ".", ""} {
+		digits, rest, err := ParseDigits(msg)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return time.Time{}, "", &Error{Expected: "timestamp", Rest: msg, Err: err}
+		}
+		fields[i] = n
 
-	minute, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
+		if sep != "" {
+			rest, err = Tag(rest, sep)
+			if err != nil {
+				return time.Time{}, "", err
+			}
+		}
+		msg = rest
 	}
 
-	msg, err = Tag(msg, ".")
-	if err != nil {
-		return "", "", err
-	}
+	year, month, day, hour, minute, second := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5]
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, loc)
 
-	second, msg, err := ParseDigits(msg)
-	if err != nil {
-		return "", "", err
+	// time.Date normalizes values that are out of range, e.g. a month of
+	// 13 becomes January of the next year. Such timestamps are invalid.
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day ||
+		t.Hour() != hour || t.Minute() != minute || t.Second() != second {
+		return time.Time{}, "", Expected("valid timestamp", start)
 	}
 
-	return fmt.Sprintf("%s.%s.%s-%s.%s.%s", year, month, day, hour, minute, second), msg, nil
+	return t, msg, nil
 }
@@ -19,6 +19,7 @@ package theislercon
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/butt4cak3/theislercon/internal/parser"
 )
@@ -49,30 +50,69 @@ const (
 	SetAIDensity      MessageType = 0x92
 )
 
+// commandNames are the names of the message types, as used in the
+// command table of the README.
+var commandNames = map[MessageType]string{
+	Auth:              "Auth",
+	ExecCommand:       "ExecCommand",
+	ResponseValue:     "ResponseValue",
+	Announce:          "Announce",
+	DirectMessage:     "DirectMessage",
+	GetServerDetails:  "GetServerDetails",
+	WipeCorpses:       "WipeCorpses",
+	UpdatePlayables:   "UpdatePlayables",
+	BanPlayer:         "BanPlayer",
+	KickPlayer:        "KickPlayer",
+	GetPlayerList:     "GetPlayerList",
+	Save:              "Save",
+	GetPlayerData:     "GetPlayerData",
+	ToggleWhitelist:   "ToggleWhitelist",
+	AddWhitelistID:    "AddWhitelistID",
+	RemoveWhitelistID: "RemoveWhitelistID",
+	ToggleGlobalChat:  "ToggleGlobalChat",
+	ToggleHumans:      "ToggleHumans",
+	ToggleAI:          "ToggleAI",
+	DisableAIClasses:  "DisableAIClasses",
+	SetAIDensity:      "SetAIDensity",
+}
+
+// CommandName returns the name of a [MessageType] constant, e.g.
+// "GetPlayerList" for 0x40. Unknown bytes are returned in hexadecimal.
+func CommandName(command MessageType) string {
+	if name, ok := commandNames[command]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", command)
+}
+
 type Response struct {
-	Timestamp string
+	Timestamp time.Time // Zero if the response has no timestamp
 	Type      string
 	Content   string
 }
 
-func parseResponse(msg string, responseType string) (*Response, error) {
-	var timestamp string
+// parseResponse parses the timestamp and the response type of msg, which
+// is the response to command. The timestamp is interpreted in loc. Errors
+// are returned as [*ParseError].
+func parseResponse(command MessageType, msg string, responseType string, loc *time.Location) (*Response, error) {
+	raw := msg
+	var timestamp time.Time
 	var err error
 
-	if msg[0] == '[' {
+	if len(msg) > 0 && msg[0] == '[' {
 		msg, err = parser.Tag(msg, "[")
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+			return nil, newParseError(command, raw, parser.InField("timestamp", err))
 		}
 
-		timestamp, msg, err = parser.ParseTimestamp(msg)
+		timestamp, msg, err = parser.ParseTimestamp(msg, loc)
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+			return nil, newParseError(command, raw, parser.InField("timestamp", err))
 		}
 
 		msg, err = parser.Tag(msg, "]")
 		if err != nil {
-			return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+			return nil, newParseError(command, raw, parser.InField("timestamp", err))
 		}
 
 		msg = parser.SkipWhitespace(msg)
@@ -80,7 +120,7 @@ func parseResponse(msg string, responseType string) (*Response, error) {
 
 	msg, err = parser.Tag(msg, responseType)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrMalformedResponse, err)
+		return nil, newParseError(command, raw, parser.InField("response type", err))
 	}
 
 	return &Response{timestamp, responseType, msg}, nil
@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// DefaultTimeout is the time that a client waits for a request to be
+// written or for the response to arrive, unless configured otherwise.
+const DefaultTimeout = 5 * time.Second
+
+// DialFunc opens a connection to the RCON server. Its signature matches
+// [net.Dialer.DialContext].
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// An Option configures a [Client]. Options are passed to [Connect].
+type Option func(*Client)
+
+// WithDialTimeout limits the time it takes to establish the connection.
+//
+// The timeout also applies when the client reconnects. By default, only the
+// context passed to [ConnectContext] limits the time.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		client.dialTimeout = timeout
+	}
+}
+
+// WithReadTimeout sets the time that the client waits for the response to
+// a command. The default is [DefaultTimeout].
+//
+// A timeout of zero means no limit, so only the context of a command
+// limits the time. A negative timeout makes [Connect] fail.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		if timeout < 0 {
+			client.invalidOption(fmt.Errorf("read timeout must not be negative, got %v", timeout))
+			return
+		}
+		client.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the time that the client may take to send a
+// command. The default is [DefaultTimeout].
+//
+// A timeout of zero means no limit, so only the context of a command
+// limits the time. A negative timeout makes [Connect] fail.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		if timeout < 0 {
+			client.invalidOption(fmt.Errorf("write timeout must not be negative, got %v", timeout))
+			return
+		}
+		client.writeTimeout = timeout
+	}
+}
+
+// invalidOption records err, unless an earlier option was invalid as
+// well.
+func (client *Client) invalidOption(err error) {
+	if client.optionErr == nil {
+		client.optionErr = err
+	}
+}
+
+// WithDialer makes the client use dialer to open connections.
+func WithDialer(dialer *net.Dialer) Option {
+	return func(client *Client) {
+		client.dial = dialer.DialContext
+	}
+}
+
+// WithDialFunc makes the client use dial to open connections.
+//
+// This can be used to connect through SSH tunnels or SOCKS proxies.
+func WithDialFunc(dial DialFunc) Option {
+	return func(client *Client) {
+		client.dial = dial
+	}
+}
+
+// WithLogger makes the client log connection events and failed commands
+// to logger. By default, nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(client *Client) {
+		client.logger = logger
+	}
+}
+
+// WithReconnect is the same as calling [Client.EnableReconnect] right
+// after connecting.
+func WithReconnect(policy ReconnectPolicy) Option {
+	return func(client *Client) {
+		client.reconnect = &policy
+	}
+}
+
+// WithMaxResponseSize is the same as calling [Client.SetMaxResponseSize]
+// right after connecting.
+func WithMaxResponseSize(size int) Option {
+	return func(client *Client) {
+		if size <= 0 {
+			size = DefaultMaxResponseSize
+		}
+		client.maxResponseSize = size
+	}
+}
+
+// WithIdleGap sets how long the client waits for more data of a response
+// that is not terminated by a NULL byte before it considers the response
+// complete. A longer gap helps with slow connections, a shorter one makes
+// responses return faster. The default is [DefaultIdleGap].
+//
+// A gap of zero or less restores [DefaultIdleGap].
+func WithIdleGap(gap time.Duration) Option {
+	return func(client *Client) {
+		if gap <= 0 {
+			gap = DefaultIdleGap
+		}
+		client.idleGap = gap
+	}
+}
+
+// WithServerLocation sets the time zone in which the server writes the
+// timestamps of its responses. The default is UTC.
+func WithServerLocation(loc *time.Location) Option {
+	return func(client *Client) {
+		client.location = loc
+	}
+}
+
+// WithStrictServerDetails makes [Client.GetServerDetails] fail if the
+// response contains a key that this library does not know. By default,
+// unknown keys are stored in [ServerDetails.Extra].
+func WithStrictServerDetails() Option {
+	return func(client *Client) {
+		client.strictServerDetails = true
+	}
+}
+
+// WithRecorder makes the client write every request and response to
+// recorder. See [Recorder] for details.
+func WithRecorder(recorder *Recorder) Option {
+	return func(client *Client) {
+		client.recorder = recorder
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []rcon.Option
+		ctxTime time.Duration
+		// connectErr is whether Connect must fail.
+		connectErr bool
+		wantErr    error
+	}{
+		{name: "default", opts: nil},
+		{name: "longer than the response", opts: []rcon.Option{rcon.WithReadTimeout(time.Second)}},
+		{name: "shorter than the response", opts: []rcon.Option{rcon.WithReadTimeout(50 * time.Millisecond)}, wantErr: os.ErrDeadlineExceeded},
+		{name: "zero read timeout", opts: []rcon.Option{rcon.WithReadTimeout(0)}},
+		{name: "zero write timeout", opts: []rcon.Option{rcon.WithWriteTimeout(0)}},
+		{name: "zero timeouts with context", opts: []rcon.Option{rcon.WithReadTimeout(0), rcon.WithWriteTimeout(0)}, ctxTime: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "negative read timeout", opts: []rcon.Option{rcon.WithReadTimeout(-time.Second)}, connectErr: true},
+		{name: "negative write timeout", opts: []rcon.Option{rcon.WithWriteTimeout(-1)}, connectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rcontest.NewServer("password")
+			defer server.Close()
+			server.SetDelay(200 * time.Millisecond)
+
+			client, err := rcon.Connect(server.Addr, tt.opts...)
+			if tt.connectErr {
+				if err == nil {
+					client.Close()
+					t.Fatal("Connect succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			if tt.ctxTime > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTime)
+				defer cancel()
+			}
+			if err := client.AuthContext(ctx, "password"); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Auth: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	var dials atomic.Int32
+	countingDial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	}
+	blockingDial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name string
+		opts []rcon.Option
+		// restart makes the server drop the connection after Connect.
+		restart   bool
+		wantErr   error
+		wantDials int32
+	}{
+		{
+			name: "dialer",
+			opts: []rcon.Option{rcon.WithDialer(&net.Dialer{Timeout: time.Second})},
+		},
+		{
+			name:      "dial func",
+			opts:      []rcon.Option{rcon.WithDialFunc(countingDial)},
+			wantDials: 1,
+		},
+		{
+			name: "dial func on reconnect",
+			opts: []rcon.Option{
+				rcon.WithDialFunc(countingDial),
+				rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 1}),
+			},
+			restart:   true,
+			wantDials: 2,
+		},
+		{
+			name:    "dial timeout",
+			opts:    []rcon.Option{rcon.WithDialFunc(blockingDial), rcon.WithDialTimeout(50 * time.Millisecond)},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dials.Store(0)
+			server := rcontest.NewServer("password")
+			defer server.Close()
+
+			client, err := rcon.Connect(server.Addr, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Connect: got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer client.Close()
+
+			if err := client.Auth("password"); err != nil {
+				t.Fatalf("Auth: %v", err)
+			}
+			if tt.restart {
+				server.CloseClientConnections()
+			}
+			if _, err := client.GetPlayerList(); err != nil {
+				t.Errorf("GetPlayerList: %v", err)
+			}
+			if got := dials.Load(); got != tt.wantDials {
+				t.Errorf("dialed %d times, want %d", got, tt.wantDials)
+			}
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	server, client := connect(t, rcon.WithLogger(logger), rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 1}))
+
+	server.CloseClientConnections()
+	if _, err := client.GetPlayerList(); err != nil {
+		t.Fatalf("GetPlayerList: %v", err)
+	}
+
+	for _, want := range []string{"rcon connected", "rcon reconnected"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []rcon.Option
+		// set is passed to SetMaxResponseSize after connecting, unless it
+		// is nil.
+		set     *int
+		wantErr error
+	}{
+		{name: "default"},
+		{name: "large enough", opts: []rcon.Option{rcon.WithMaxResponseSize(4096)}},
+		{name: "too small", opts: []rcon.Option{rcon.WithMaxResponseSize(100)}, wantErr: rcon.ErrResponseTooLarge},
+		{name: "zero restores the default", opts: []rcon.Option{rcon.WithMaxResponseSize(0)}},
+		{name: "set too small", set: ptr(100), wantErr: rcon.ErrResponseTooLarge},
+		{name: "set restores the default", opts: []rcon.Option{rcon.WithMaxResponseSize(100)}, set: ptr(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := connect(t, tt.opts...)
+			if tt.set != nil {
+				client.SetMaxResponseSize(*tt.set)
+			}
+			if _, err := client.GetServerDetails(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetServerDetails: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestServerLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2025, 6, 1, 12, 30, 45, 0, loc)
+
+	tests := []struct {
+		name string
+		opts []rcon.Option
+		want time.Time
+	}{
+		{"default", nil, time.Date(2025, 6, 1, 12, 30, 45, 0, time.UTC)},
+		{"server location", []rcon.Option{rcon.WithServerLocation(loc)}, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, tt.opts...)
+			server.Now = func() time.Time { return now }
+
+			_, got, err := client.GetPlayerDataWithTimestamp()
+			if err != nil {
+				t.Fatalf("GetPlayerDataWithTimestamp: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("timestamp %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,8 +17,15 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
 package theislercon
 
+import (
+	"strings"
+	"time"
+
+	"github.com/butt4cak3/theislercon/internal/parser"
+)
+
 type Player struct {
-	ID        string // Steam or EOS ID
+	ID        PlayerID
 	Name      string
 	Location  Location
 	DinoClass DinoClass
@@ -34,3 +41,31 @@ type Location struct {
 	Y float64 // Longitude
 	Z float64 // Altitude
 }
+
+// parsePlayerList parses the response to GetPlayerList.
+//
+// The content is a comma separated list with three values per player, of
+// which the first two are the ID and the name.
+func parsePlayerList(msg string) ([]Player, error) {
+	response, err := parseResponse(GetPlayerList, msg, "PlayerList", time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(response.Content, ",")
+	players := make([]Player, 0, len(lines)/3)
+
+	for i := 0; i < len(lines); i += 3 {
+		playerID := strings.TrimSpace(lines[i])
+		if playerID == "" {
+			break
+		}
+		if i+1 >= len(lines) {
+			return nil, newParseError(GetPlayerList, msg, parser.InField("Name", parser.Expected(`","`, "")))
+		}
+		name := strings.TrimSpace(lines[i+1])
+		players = append(players, Player{ID: PlayerID(playerID), Name: name})
+	}
+
+	return players, nil
+}
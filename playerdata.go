@@ -18,162 +18,266 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package theislercon
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/butt4cak3/theislercon/internal/parser"
 )
 
-func parsePlayerDataMessage(message string) ([]Player, error) {
-	response, err := parseResponse(message, "PlayerData")
+// A PlayerDataError describes a player record in the PlayerData response
+// that could not be parsed.
+type PlayerDataError struct {
+	Index  int // Position of the record in the response, starting at 0
+	Offset int // Byte offset in the response at which parsing failed
+	Err    error
+}
+
+func (e *PlayerDataError) Error() string {
+	return fmt.Sprintf("player record %d: %v", e.Index, e.Err)
+}
+
+func (e *PlayerDataError) Unwrap() error {
+	return e.Err
+}
+
+func parsePlayerDataMessage(message string, loc *time.Location) ([]Player, time.Time, error) {
+	timestamp, players, _, err := parsePlayerData(message, false, loc)
+	return players, timestamp, err
+}
+
+// parsePlayerData parses the records of a PlayerData response.
+//
+// Records are usually separated by newlines, but player names can contain
+// almost anything, including commas, colons, newlines and the text
+// ", PlayerID:". Therefore, the parser does not split the response into
+// lines. Instead, it tries every ", PlayerID:" after the start of a name
+// and uses the first one that is followed by a complete record. The only
+// thing that cannot be part of a name is a line that starts with "Name:".
+//
+// In lenient mode, a record that cannot be parsed is skipped up to the
+// next line that starts with "Name:" and the error is collected. Otherwise,
+// the first error is returned.
+func parsePlayerData(message string, lenient bool, loc *time.Location) (time.Time, []Player, []*PlayerDataError, error) {
+	response, err := parseResponse(GetPlayerData, message, "PlayerData", loc)
 	if err != nil {
-		return nil, err
+		return time.Time{}, nil, nil, err
 	}
 
-	message = response.Content
-
-	lines := strings.Split(message, "\n")
+	content := response.Content
+	base := len(message) - len(content)
+	offset := func(rest string) int {
+		return base + len(content) - len(rest)
+	}
 
 	result := make([]Player, 0)
+	var errs []*PlayerDataError
 
-	for _, line := range lines {
-		msg := parser.SkipWhitespace(line)
+	msg := content
+	for index := 0; ; index++ {
+		msg = parser.SkipWhitespace(msg)
 		if msg == "" {
-			continue
+			break
 		}
 
-		msg, err := parser.Tag(msg, "Name:")
+		player, rest, err := parsePlayerRecord(msg)
 		if err != nil {
-			return nil, err
+			recordErr := &PlayerDataError{index, offset(rest), newParseError(GetPlayerData, message, err)}
+			var parseErr *ParseError
+			if errors.As(recordErr.Err, &parseErr) {
+				recordErr.Offset = parseErr.Offset
+			}
+			if !lenient {
+				return time.Time{}, nil, nil, recordErr
+			}
+			errs = append(errs, recordErr)
+			msg = skipPlayerRecord(msg)
+			continue
 		}
 
-		msg = parser.SkipWhitespace(msg)
-
-		pidIndex := strings.LastIndex(msg, ", PlayerID:")
-		name, msg := parser.Take(msg, pidIndex)
-		_, msg = parser.Take(msg, 1)
-
-		msg = parser.SkipWhitespace(msg)
+		result = append(result, player)
+		msg = rest
+	}
 
-		msg, err = parser.Tag(msg, "PlayerID:")
-		if err != nil {
-			return nil, err
-		}
+	return response.Timestamp, result, errs, nil
+}
 
-		msg = parser.SkipWhitespace(msg)
+// skipPlayerRecord returns msg from the start of the next line that
+// starts with "Name:".
+func skipPlayerRecord(msg string) string {
+	i := strings.Index(msg, "\nName:")
+	if i < 0 {
+		return ""
+	}
+	return msg[i+1:]
+}
 
-		playerId, msg, err := parser.ParseDigits(msg)
-		if err != nil {
-			return nil, err
-		}
+// parsePlayerRecord parses one player record. On failure, the returned
+// string starts where the parser failed.
+func parsePlayerRecord(msg string) (Player, string, error) {
+	rest, err := parser.Tag(msg, "Name:")
+	if err != nil {
+		return Player{}, msg, err
+	}
 
-		msg = parser.SkipWhitespace(msg)
+	name := parser.SkipWhitespace(rest)
 
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
-		}
+	// A line that starts with "Name:" almost certainly belongs to the next
+	// record. Not looking past it keeps a broken record from swallowing
+	// the next one.
+	limit := strings.Index(name, "\nName:")
+	if limit < 0 {
+		limit = len(name)
+	}
 
-		msg = parser.SkipWhitespace(msg)
+	var firstErr error
+	var firstRest string
 
-		msg, err = parser.Tag(msg, "Location:")
-		if err != nil {
-			return nil, err
+	for pos := 0; ; pos++ {
+		i := strings.Index(name[pos:limit], ", PlayerID:")
+		if i < 0 {
+			break
 		}
+		pos += i
 
-		msg = parser.SkipWhitespace(msg)
-
-		x, msg, err := parseLocationField(msg, "X")
-		if err != nil {
-			return nil, err
+		player, rest, err := parsePlayerFields(name[pos+1:])
+		if err == nil && !isRecordEnd(rest) {
+			err = parser.Expected("end of record", rest)
 		}
-		y, msg, err := parseLocationField(msg, "Y")
-		if err != nil {
-			return nil, err
-		}
-		z, msg, err := parseLocationField(msg, "Z")
-		if err != nil {
-			return nil, err
-		}
-
-		msg = parser.SkipWhitespace(msg)
-
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
+		if err == nil {
+			player.Name = name[:pos]
+			return player, rest, nil
 		}
 
-		msg = parser.SkipWhitespace(msg)
-
-		msg, err = parser.Tag(msg, "Class:")
-		if err != nil {
-			return nil, err
+		if firstErr == nil {
+			firstErr = err
+			firstRest = rest
 		}
+	}
 
-		msg = parser.SkipWhitespace(msg)
-
-		class, msg, err := parseClassName(msg)
-		if err != nil {
-			return nil, err
-		}
+	if firstErr == nil {
+		return Player{}, name, parser.InField("Name", parser.Expected(`", PlayerID:"`, name))
+	}
+	return Player{}, firstRest, firstErr
+}
 
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
-		}
+// isRecordEnd reports whether msg is at the end of a player record.
+func isRecordEnd(msg string) bool {
+	msg = parser.SkipWhitespace(msg)
+	return msg == "" || strings.HasPrefix(msg, "Name:")
+}
 
-		growth, msg, err := parsePercentageField(msg, "Growth")
-		if err != nil {
-			return nil, err
-		}
+// parsePlayerFields parses everything after the name of a player. On
+// failure, the returned string starts where the parser failed.
+func parsePlayerFields(msg string) (Player, string, error) {
+	var player Player
 
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
-		}
+	msg, err := parseLabel(msg, "PlayerID:")
+	if err != nil {
+		return player, msg, parser.InField("PlayerID", err)
+	}
 
-		health, msg, err := parsePercentageField(msg, "Health")
-		if err != nil {
-			return nil, err
-		}
+	id, rest, err := parsePlayerID(msg)
+	if err != nil {
+		return player, msg, parser.InField("PlayerID", err)
+	}
+	player.ID = id
+	msg = parser.SkipWhitespace(rest)
 
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
-		}
+	msg, err = parseLabel(msg, ",")
+	if err != nil {
+		return player, msg, parser.InField("Location", err)
+	}
+	msg, err = parseLabel(msg, "Location:")
+	if err != nil {
+		return player, msg, parser.InField("Location", err)
+	}
 
-		stamina, msg, err := parsePercentageField(msg, "Stamina")
+	for _, field := range []struct {
+		name string
+		dest *float64
+	}{
+		{"X", &player.Location.X},
+		{"Y", &player.Location.Y},
+		{"Z", &player.Location.Z},
+	} {
+		v, rest, err := parseLocationField(msg, field.name)
 		if err != nil {
-			return nil, err
+			return player, msg, parser.InField("Location."+field.name, err)
 		}
+		*field.dest = v
+		msg = rest
+	}
 
-		msg, err = parser.Tag(msg, ",")
-		if err != nil {
-			return nil, err
-		}
+	msg = parser.SkipWhitespace(msg)
 
-		hunger, msg, err := parsePercentageField(msg, "Hunger")
-		if err != nil {
-			return nil, err
-		}
+	msg, err = parseLabel(msg, ",")
+	if err != nil {
+		return player, msg, parser.InField("Class", err)
+	}
+	msg, err = parseLabel(msg, "Class:")
+	if err != nil {
+		return player, msg, parser.InField("Class", err)
+	}
 
-		msg, err = parser.Tag(msg, ",")
+	class, rest, err := parseClassName(msg)
+	if err != nil {
+		return player, msg, parser.InField("Class", err)
+	}
+	player.DinoClass = DinoClass(class)
+	msg = rest
+
+	for _, field := range []struct {
+		name string
+		dest *int8
+	}{
+		{"Growth", &player.Growth},
+		{"Health", &player.Health},
+		{"Stamina", &player.Stamina},
+		{"Hunger", &player.Hunger},
+		{"Thirst", &player.Thirst},
+	} {
+		rest, err := parser.Tag(msg, ",")
 		if err != nil {
-			return nil, err
+			return player, msg, parser.InField(field.name, err)
 		}
+		msg = rest
 
-		thirst, _, err := parsePercentageField(msg, "Thirst")
+		v, rest, err := parsePercentageField(msg, field.name)
 		if err != nil {
-			return nil, err
+			return player, msg, parser.InField(field.name, err)
 		}
+		*field.dest = v
+		msg = rest
+	}
 
-		class = class[3 : len(class)-2]
+	return player, msg, nil
+}
 
-		player := Player{playerId, name, Location{x, y, z}, DinoClass(class), growth, health, stamina, hunger, thirst}
-		result = append(result, player)
+// parsePlayerID parses a Steam or EOS ID, which both consist of ASCII
+// letters and digits. The ID is not validated any further, so that
+// unexpected IDs still show up in the results.
+func parsePlayerID(msg string) (PlayerID, string, error) {
+	pos := 0
+	for pos < len(msg) && (parser.IsAsciiDigit(msg[pos]) || parser.IsAsciiLetter(msg[pos])) {
+		pos++
 	}
+	if pos == 0 {
+		return "", "", parser.Expected("player ID", msg)
+	}
+	return PlayerID(msg[:pos]), msg[pos:], nil
+}
 
-	return result, nil
+// parseLabel expects tag, surrounded by optional whitespace. On failure,
+// it returns msg unchanged.
+func parseLabel(msg, tag string) (string, error) {
+	rest, err := parser.Tag(parser.SkipWhitespace(msg), tag)
+	if err != nil {
+		return msg, err
+	}
+	return parser.SkipWhitespace(rest), nil
 }
 
 func parseLocationField(msg, name string) (float64, string, error) {
@@ -208,22 +312,36 @@ func parsePercentageField(msg, name string) (int8, string, error) {
 		return 0, "", err
 	}
 	msg = parser.SkipWhitespace(msg)
-	f, msg, err := parser.ParseFloat64(msg)
+	f, rest, err := parser.ParseFloat64(msg)
 	if err != nil {
 		return 0, "", err
 	}
-	return int8(f * 100), msg, nil
+	// The server sends fractions like 0.75, which are rounded to the
+	// nearest percent. Anything that does not fit into an int8 is an
+	// error rather than a wrapped value.
+	v := math.Round(f * 100)
+	if v < math.MinInt8 || v > math.MaxInt8 {
+		return 0, "", parser.Expected("value between -1.28 and 1.27", msg)
+	}
+	return int8(v), rest, nil
 }
 
+// parseClassName parses a class name like BP_Troodon_C and returns the
+// name of the class without the prefix and suffix.
 func parseClassName(msg string) (string, string, error) {
 	pos := 0
-	for pos < len(msg) && (msg[pos] == '_' || parser.IsAsciiLetter(msg[pos])) {
+	for pos < len(msg) && (msg[pos] == '_' || parser.IsAsciiLetter(msg[pos]) || parser.IsAsciiDigit(msg[pos])) {
 		pos++
 	}
-	if pos > 0 {
-		return msg[:pos], msg[pos:], nil
-	} else {
-		return "", "", fmt.Errorf("expected class name")
+	if pos == 0 {
+		return "", "", parser.Expected("class name", msg)
+	}
 
+	class := msg[:pos]
+	class = strings.TrimPrefix(class, "BP_")
+	class = strings.TrimSuffix(class, "_C")
+	if class == "" {
+		return "", "", parser.Expected("class name", msg)
 	}
+	return class, msg[pos:], nil
 }
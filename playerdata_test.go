@@ -0,0 +1,202 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestPlayerDataNames(t *testing.T) {
+	names := []string{
+		"Alice",
+		"Al, ice",
+		"Alice, PlayerID: 76561198000000009",
+		"Key: value",
+		"Grüße 🦖",
+		"two\nlines",
+		"ends with comma,",
+		"trailing space ",
+		"Name",
+		"Name: Eve, PlayerID: 76561198000000099, Location: X=1 Y=2 Z=3",
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			server, client := connect(t)
+			want := []rcon.Player{
+				{ID: "76561198000000001", Name: name, DinoClass: rcon.Troodon, Growth: 50, Health: 100},
+				{ID: "76561198000000002", Name: "Bob", DinoClass: rcon.Stegosaurus, Growth: 75, Health: 90},
+			}
+			server.Update(func(world *rcontest.World) { world.Players = want })
+
+			got, err := client.GetPlayerData()
+			if err != nil {
+				t.Fatalf("GetPlayerData: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetPlayerData() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPlayerDataLenient(t *testing.T) {
+	const (
+		alice = "Name: Alice, PlayerID: 76561198000000001, Location: X=1.000 Y=2.000 Z=3.000, Class: BP_Troodon_C, Growth: 0.50, Health: 1.00, Stamina: 1.00, Hunger: 1.00, Thirst: 1.00\n"
+		bob   = "Name: Bob, PlayerID: 76561198000000002, Location: X=1.000 Y=2.000 Z=3.000, Class: BP_Stegosaurus_C, Growth: 0.75, Health: 1.00, Stamina: 1.00, Hunger: 1.00, Thirst: 1.00\n"
+	)
+
+	tests := []struct {
+		name      string
+		records   []string
+		wantNames []string
+		// wantSkipped are the indexes of the skipped records.
+		wantSkipped []int
+	}{
+		{
+			name:      "valid",
+			records:   []string{alice, bob},
+			wantNames: []string{"Alice", "Bob"},
+		},
+		{
+			name:        "missing class",
+			records:     []string{alice, strings.Replace(bob, "BP_Stegosaurus_C", "", 1), alice},
+			wantNames:   []string{"Alice", "Alice"},
+			wantSkipped: []int{1},
+		},
+		{
+			name:        "invalid number",
+			records:     []string{strings.Replace(alice, "Growth: 0.50", "Growth: lots", 1), bob},
+			wantNames:   []string{"Bob"},
+			wantSkipped: []int{0},
+		},
+		{
+			name:        "cut off",
+			records:     []string{alice, bob[:40]},
+			wantNames:   []string{"Alice"},
+			wantSkipped: []int{1},
+		},
+		{
+			name:        "missing player ID",
+			records:     []string{strings.Replace(alice, ", PlayerID: 76561198000000001", "", 1), bob},
+			wantNames:   []string{"Bob"},
+			wantSkipped: []int{0},
+		},
+		{
+			name:        "all broken",
+			records:     []string{"Name: x\n", "Name: y\n"},
+			wantSkipped: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := "PlayerData\n" + strings.Join(tt.records, "")
+
+			client := replay(t, "\x02\x77", response)
+			players, skipped, err := client.GetPlayerDataLenient()
+			if err != nil {
+				t.Fatalf("GetPlayerDataLenient: %v", err)
+			}
+
+			var names []string
+			for _, player := range players {
+				names = append(names, player.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("got players %q, want %q", names, tt.wantNames)
+			}
+
+			var indexes []int
+			for _, e := range skipped {
+				indexes = append(indexes, e.Index)
+				if e.Offset < len("PlayerData\n") || e.Offset > len(response) {
+					t.Errorf("record %d: offset %d is outside of the records", e.Index, e.Offset)
+				}
+			}
+			if !reflect.DeepEqual(indexes, tt.wantSkipped) {
+				t.Errorf("skipped records %v, want %v", indexes, tt.wantSkipped)
+			}
+
+			// Without lenient mode, the first broken record fails the call.
+			client = replay(t, "\x02\x77", response)
+			_, err = client.GetPlayerData()
+			var recordErr *rcon.PlayerDataError
+			switch {
+			case len(tt.wantSkipped) == 0 && err != nil:
+				t.Errorf("GetPlayerData: %v", err)
+			case len(tt.wantSkipped) > 0 && !errors.As(err, &recordErr):
+				t.Errorf("GetPlayerData: got error %v, want a *PlayerDataError", err)
+			case len(tt.wantSkipped) > 0 && recordErr.Index != tt.wantSkipped[0]:
+				t.Errorf("GetPlayerData: error in record %d, want %d", recordErr.Index, tt.wantSkipped[0])
+			}
+		})
+	}
+}
+
+func TestPlayerDataPercentages(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int8
+		wantErr bool
+	}{
+		{value: "0.00", want: 0},
+		{value: "1.00", want: 100},
+		{value: "0.29", want: 29},
+		{value: "0.005", want: 1},
+		{value: "-0.50", want: -50},
+		{value: "1.27", want: 127},
+		{value: "1.274", want: 127},
+		{value: "-1.28", want: -128},
+		{value: "1.28", wantErr: true},
+		{value: "2.56", wantErr: true},
+		{value: "100", wantErr: true},
+		{value: "-1.29", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			response := "PlayerData\nName: Alice, PlayerID: 76561198000000001, Location: X=1.000 Y=2.000 Z=3.000, Class: BP_Troodon_C, Growth: 0.50, Health: " + tt.value + ", Stamina: 1.00, Hunger: 1.00, Thirst: 1.00\n"
+
+			client := replay(t, "\x02\x77", response)
+			players, err := client.GetPlayerData()
+			if tt.wantErr {
+				var parseErr *rcon.ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("GetPlayerData: got %v, want a *ParseError", err)
+				}
+				if !strings.Contains(err.Error(), "Health") {
+					t.Errorf("error %q does not name the field", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetPlayerData: %v", err)
+			}
+			if len(players) != 1 || players[0].Health != tt.want {
+				t.Errorf("got players %+v, want Health %d", players, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PlayerID identifies a player. Depending on the platform, it is either
+// a Steam ID in its 64 bit form (e.g. 76561197960287930) or an Epic
+// Online Services product user ID (32 hexadecimal digits).
+type PlayerID string
+
+// PlayerIDKind is the platform that a [PlayerID] belongs to.
+type PlayerIDKind int
+
+const (
+	UnknownID PlayerIDKind = iota
+	SteamID
+	EOSID
+)
+
+func (kind PlayerIDKind) String() string {
+	switch kind {
+	case SteamID:
+		return "Steam"
+	case EOSID:
+		return "EOS"
+	default:
+		return "Unknown"
+	}
+}
+
+// steamIDBase is the 64 bit Steam ID of the account with ID 0 in the
+// public universe. Steam IDs of individual accounts are this number plus
+// the 32 bit account ID.
+const steamIDBase = 76561197960265728
+
+// ParsePlayerID checks that s is a valid Steam or EOS ID.
+//
+// If it is not, the error wraps [ErrInvalidPlayerID].
+func ParsePlayerID(s string) (PlayerID, error) {
+	id := PlayerID(s)
+	if !id.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPlayerID, s)
+	}
+	return id, nil
+}
+
+// Kind returns the platform that the ID belongs to.
+func (id PlayerID) Kind() PlayerIDKind {
+	if _, ok := id.steamID64(); ok {
+		return SteamID
+	}
+	if isEOSID(string(id)) {
+		return EOSID
+	}
+	return UnknownID
+}
+
+// Valid reports whether id is a Steam or EOS ID.
+func (id PlayerID) Valid() bool {
+	return id.Kind() != UnknownID
+}
+
+// IsSteam reports whether id is a Steam ID.
+func (id PlayerID) IsSteam() bool {
+	return id.Kind() == SteamID
+}
+
+// IsEOS reports whether id is an EOS ID.
+func (id PlayerID) IsEOS() bool {
+	return id.Kind() == EOSID
+}
+
+func (id PlayerID) String() string {
+	return string(id)
+}
+
+// SteamID64 returns the Steam ID as a number.
+func (id PlayerID) SteamID64() (uint64, error) {
+	n, ok := id.steamID64()
+	if !ok {
+		return 0, fmt.Errorf("%w: %q is not a Steam ID", ErrInvalidPlayerID, string(id))
+	}
+	return n, nil
+}
+
+// SteamID32 returns the account ID of a Steam ID, which is the lower 32
+// bits of the 64 bit ID.
+func (id PlayerID) SteamID32() (uint32, error) {
+	n, err := id.SteamID64()
+	if err != nil {
+		return 0, err
+	}
+	return uint32(n - steamIDBase), nil
+}
+
+// SteamID3 returns the Steam ID in the form [U:1:<account ID>].
+func (id PlayerID) SteamID3() (string, error) {
+	account, err := id.SteamID32()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[U:1:%d]", account), nil
+}
+
+// SteamProfileURL returns the URL of the Steam community profile.
+func (id PlayerID) SteamProfileURL() (string, error) {
+	n, err := id.SteamID64()
+	if err != nil {
+		return "", err
+	}
+	return "https://steamcommunity.com/profiles/" + strconv.FormatUint(n, 10), nil
+}
+
+// steamID64 parses id as a Steam ID of an individual account in the
+// public universe.
+func (id PlayerID) steamID64() (uint64, bool) {
+	s := string(id)
+	if len(s) != 17 {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if n < steamIDBase || n-steamIDBase > 0xFFFFFFFF {
+		return 0, false
+	}
+	return n, true
+}
+
+func isEOSID(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+func TestParsePlayerID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want rcon.PlayerIDKind
+	}{
+		{"steam", "76561197960287930", rcon.SteamID},
+		{"steam first account", "76561197960265729", rcon.SteamID},
+		{"steam last account", "76561202255233023", rcon.SteamID},
+		{"eos", "0002a1b2c3d4e5f60718293a4b5c6d7e", rcon.EOSID},
+		{"eos upper case", "0002A1B2C3D4E5F60718293A4B5C6D7E", rcon.EOSID},
+		{"empty", "", rcon.UnknownID},
+		{"steam below range", "76561197960265727", rcon.UnknownID},
+		{"steam above range", "76561202255233024", rcon.UnknownID},
+		{"steam too short", "7656119796028793", rcon.UnknownID},
+		{"steam too long", "765611979602879300", rcon.UnknownID},
+		{"steam with sign", "+6561197960287930", rcon.UnknownID},
+		{"steam with space", " 6561197960287930", rcon.UnknownID},
+		{"steam overflow", "99999999999999999", rcon.UnknownID},
+		{"eos too short", "0002a1b2c3d4e5f60718293a4b5c6d7", rcon.UnknownID},
+		{"eos too long", "0002a1b2c3d4e5f60718293a4b5c6d7e8", rcon.UnknownID},
+		{"eos not hex", "0002a1b2c3d4e5f60718293a4b5c6d7g", rcon.UnknownID},
+		{"name", "Alice", rcon.UnknownID},
+		{"steam id 3", "[U:1:22202]", rcon.UnknownID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := rcon.PlayerID(tt.id)
+			if got := id.Kind(); got != tt.want {
+				t.Errorf("Kind() = %v, want %v", got, tt.want)
+			}
+			if got := id.IsSteam(); got != (tt.want == rcon.SteamID) {
+				t.Errorf("IsSteam() = %v", got)
+			}
+			if got := id.IsEOS(); got != (tt.want == rcon.EOSID) {
+				t.Errorf("IsEOS() = %v", got)
+			}
+
+			parsed, err := rcon.ParsePlayerID(tt.id)
+			if tt.want == rcon.UnknownID {
+				if !errors.Is(err, rcon.ErrInvalidPlayerID) {
+					t.Errorf("ParsePlayerID returned error %v, want %v", err, rcon.ErrInvalidPlayerID)
+				}
+				return
+			}
+			if err != nil || parsed != id {
+				t.Errorf("ParsePlayerID = %q, %v, want %q", parsed, err, tt.id)
+			}
+		})
+	}
+}
+
+func TestSteamIDConversions(t *testing.T) {
+	tests := []struct {
+		id      rcon.PlayerID
+		id32    uint32
+		id3     string
+		profile string
+	}{
+		{"76561197960287930", 22202, "[U:1:22202]", "https://steamcommunity.com/profiles/76561197960287930"},
+		{"76561197960265729", 1, "[U:1:1]", "https://steamcommunity.com/profiles/76561197960265729"},
+		{"76561198000000001", 39734273, "[U:1:39734273]", "https://steamcommunity.com/profiles/76561198000000001"},
+		{"76561202255233023", 4294967295, "[U:1:4294967295]", "https://steamcommunity.com/profiles/76561202255233023"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			if got, err := tt.id.SteamID32(); err != nil || got != tt.id32 {
+				t.Errorf("SteamID32() = %d, %v, want %d", got, err, tt.id32)
+			}
+			if got, err := tt.id.SteamID3(); err != nil || got != tt.id3 {
+				t.Errorf("SteamID3() = %q, %v, want %q", got, err, tt.id3)
+			}
+			if got, err := tt.id.SteamProfileURL(); err != nil || got != tt.profile {
+				t.Errorf("SteamProfileURL() = %q, %v, want %q", got, err, tt.profile)
+			}
+		})
+	}
+}
+
+func TestSteamIDConversionsRejectOthers(t *testing.T) {
+	for _, id := range []rcon.PlayerID{"0002a1b2c3d4e5f60718293a4b5c6d7e", "76561197960265727", "Alice", ""} {
+		if _, err := id.SteamID64(); !errors.Is(err, rcon.ErrInvalidPlayerID) {
+			t.Errorf("%q: SteamID64 returned error %v, want %v", id, err, rcon.ErrInvalidPlayerID)
+		}
+		if _, err := id.SteamID32(); !errors.Is(err, rcon.ErrInvalidPlayerID) {
+			t.Errorf("%q: SteamID32 returned error %v, want %v", id, err, rcon.ErrInvalidPlayerID)
+		}
+		if _, err := id.SteamID3(); !errors.Is(err, rcon.ErrInvalidPlayerID) {
+			t.Errorf("%q: SteamID3 returned error %v, want %v", id, err, rcon.ErrInvalidPlayerID)
+		}
+		if _, err := id.SteamProfileURL(); !errors.Is(err, rcon.ErrInvalidPlayerID) {
+			t.Errorf("%q: SteamProfileURL returned error %v, want %v", id, err, rcon.ErrInvalidPlayerID)
+		}
+	}
+}
@@ -0,0 +1,721 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// HealthCheckInterval is the time that a connection of a [Pool] may be
+// idle before the pool checks that it still works.
+const HealthCheckInterval = time.Minute
+
+// A Pool sends commands over several connections to the same server, so
+// that a slow command does not hold up the others.
+//
+// A pool has the same command methods as [Client]. Each call takes an
+// idle connection, or opens a new one as long as there are fewer than
+// the size of the pool, or waits for one to become free.
+//
+// When commands wait for a connection, the next free one goes to the
+// command with the highest [Priority]. Polling commands ([PriorityLow])
+// never use the last connection of a pool with more than one, so a kick
+// does not have to wait for a slow GetPlayerData.
+//
+// Connections that have been idle for [HealthCheckInterval] are checked
+// with a GetPlayerList, which counts as a polling command. The connections of a pool reconnect and
+// authenticate again when they break; the options given to [NewPool] may
+// replace the [ReconnectPolicy].
+type Pool struct {
+	addr     string
+	password string
+	size     int
+	opts     []Option
+
+	mu      sync.Mutex
+	clients []*Client
+	idle    []idleClient
+	// open is the number of clients that are idle, in use or still
+	// connecting.
+	open int
+	// polls is the number of clients in use by polling commands.
+	polls   int
+	waiting [PriorityHigh + 1][]*poolWaiter
+	closed  bool
+
+	done chan struct{}
+}
+
+type idleClient struct {
+	client *Client
+	since  time.Time
+}
+
+// A poolWaiter waits for a client. It receives either an idle client or
+// nil, which allows it to open a new one.
+type poolWaiter struct {
+	ready chan *Client
+}
+
+var errPoolSize = errors.New("pool size must be at least 1")
+
+// NewPool opens a pool of at most size connections to addr, which are
+// authenticated with password. Every client is created with opts.
+//
+// NewPool opens the first connection right away, so that an unreachable
+// server or a wrong password is reported early. The others are opened
+// when they are needed.
+func NewPool(ctx context.Context, addr, password string, size int, opts ...Option) (*Pool, error) {
+	if size < 1 {
+		return nil, errPoolSize
+	}
+
+	pool := &Pool{
+		addr:     addr,
+		password: password,
+		size:     size,
+		opts:     append([]Option{WithReconnect(ReconnectPolicy{MaxAttempts: 3})}, opts...),
+		done:     make(chan struct{}),
+	}
+
+	client, err := pool.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pool.clients = append(pool.clients, client)
+	pool.idle = append(pool.idle, idleClient{client, time.Now()})
+	pool.open = 1
+
+	go pool.checkHealth()
+
+	return pool, nil
+}
+
+func (pool *Pool) connect(ctx context.Context) (*Client, error) {
+	client, err := ConnectContext(ctx, pool.addr, pool.opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.AuthContext(ctx, pool.password); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// maxPolls returns the number of clients that polling commands may use
+// at the same time.
+func (pool *Pool) maxPolls() int {
+	return max(pool.size-1, 1)
+}
+
+// canTake reports whether a command of priority prio may take a client
+// without waiting. The caller must hold pool.mu.
+func (pool *Pool) canTake(prio Priority) bool {
+	for p := prio; p <= PriorityHigh; p++ {
+		if len(pool.waiting[p]) > 0 {
+			return false
+		}
+	}
+	return prio != PriorityLow || pool.polls < pool.maxPolls()
+}
+
+// take returns an idle client, or nil if the caller should open a new
+// one. It returns false if there is neither. The caller must hold
+// pool.mu.
+func (pool *Pool) take(prio Priority) (*Client, bool) {
+	var client *Client
+	if n := len(pool.idle); n > 0 {
+		client = pool.idle[n-1].client
+		pool.idle = pool.idle[:n-1]
+	} else if pool.open < pool.size {
+		pool.open++
+	} else {
+		return nil, false
+	}
+
+	if prio == PriorityLow {
+		pool.polls++
+	}
+	return client, true
+}
+
+// acquire returns a client for a command of priority prio. It must be
+// given back with release.
+func (pool *Pool) acquire(ctx context.Context, prio Priority) (*Client, error) {
+	pool.mu.Lock()
+	if pool.closed {
+		pool.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+
+	if pool.canTake(prio) {
+		if client, ok := pool.take(prio); ok {
+			pool.mu.Unlock()
+			if client == nil {
+				return pool.openClient(ctx, prio)
+			}
+			return client, nil
+		}
+	}
+
+	w := &poolWaiter{ready: make(chan *Client, 1)}
+	pool.waiting[prio] = append(pool.waiting[prio], w)
+	pool.mu.Unlock()
+
+	var err error
+	select {
+	case client := <-w.ready:
+		if client == nil {
+			return pool.openClient(ctx, prio)
+		}
+		return client, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-pool.done:
+		err = net.ErrClosed
+	}
+
+	pool.mu.Lock()
+	if i := slices.Index(pool.waiting[prio], w); i >= 0 {
+		pool.waiting[prio] = slices.Delete(pool.waiting[prio], i, i+1)
+		pool.mu.Unlock()
+		return nil, err
+	}
+	pool.mu.Unlock()
+
+	// The waiter was served in the meantime.
+	if client := <-w.ready; client != nil {
+		pool.release(client, prio)
+	} else {
+		pool.abandon(prio)
+	}
+	return nil, err
+}
+
+// openClient opens a new client after take allowed it.
+func (pool *Pool) openClient(ctx context.Context, prio Priority) (*Client, error) {
+	client, err := pool.connect(ctx)
+	if err != nil {
+		pool.abandon(prio)
+		return nil, err
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.closed {
+		client.Close()
+		return nil, net.ErrClosed
+	}
+	pool.clients = append(pool.clients, client)
+	return client, nil
+}
+
+// abandon gives up the right to open a client.
+func (pool *Pool) abandon(prio Priority) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.open--
+	if prio == PriorityLow {
+		pool.polls--
+	}
+	pool.dispatch()
+}
+
+// release gives a client back to the pool.
+func (pool *Pool) release(client *Client, prio Priority) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if prio == PriorityLow {
+		pool.polls--
+	}
+	if pool.closed {
+		pool.open--
+		return
+	}
+	pool.idle = append(pool.idle, idleClient{client, time.Now()})
+	pool.dispatch()
+}
+
+// dispatch hands free clients to waiting commands, highest priority
+// first.
+// The caller must hold pool.mu.
+func (pool *Pool) dispatch() {
+	for prio := PriorityHigh; prio >= PriorityLow; prio-- {
+		for len(pool.waiting[prio]) > 0 {
+			if prio == PriorityLow && pool.polls >= pool.maxPolls() {
+				break
+			}
+			client, ok := pool.take(prio)
+			if !ok {
+				return
+			}
+			w := pool.waiting[prio][0]
+			pool.waiting[prio] = pool.waiting[prio][1:]
+			w.ready <- client
+		}
+	}
+}
+
+// do runs fn with a client of the pool.
+func (pool *Pool) do(ctx context.Context, command MessageType, fn func(client *Client) error) error {
+	prio := defaultPriority(command)
+	client, err := pool.acquire(ctx, prio)
+	if err != nil {
+		return err
+	}
+	defer pool.release(client, prio)
+	return fn(client)
+}
+
+// checkHealth sends a command over every connection that has been idle
+// for too long, so that broken connections are replaced before a command
+// needs them.
+func (pool *Pool) checkHealth() {
+	ticker := time.NewTicker(HealthCheckInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-pool.done:
+			return
+		}
+		pool.checkIdle(time.Now().Add(-HealthCheckInterval))
+	}
+}
+
+// checkIdle sends a GetPlayerList over every connection that has been
+// idle since before cutoff. The checks count as polls, so they never use
+// the last free connection and give way to waiting commands.
+func (pool *Pool) checkIdle(cutoff time.Time) {
+	for {
+		client := pool.acquireIdle(cutoff)
+		if client == nil {
+			return
+		}
+		// Without a read timeout, a check that never gets a response
+		// would keep the connection forever.
+		ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(client.readTimeout, DefaultTimeout))
+		// Errors are logged by the client. A broken connection is
+		// replaced by the next command.
+		client.ExecCommandContext(ctx, GetPlayerList)
+		cancel()
+		pool.release(client, PriorityLow)
+	}
+}
+
+// acquireIdle takes the client that has been idle the longest, if it has
+// been idle since before cutoff and a poll may be started now. Otherwise,
+// it returns nil.
+func (pool *Pool) acquireIdle(cutoff time.Time) *Client {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.closed || !pool.canTake(PriorityLow) {
+		return nil
+	}
+	i := slices.IndexFunc(pool.idle, func(idle idleClient) bool { return idle.since.Before(cutoff) })
+	if i < 0 {
+		return nil
+	}
+	client := pool.idle[i].client
+	pool.idle = slices.Delete(pool.idle, i, i+1)
+	pool.polls++
+	return client
+}
+
+// Close closes all connections of the pool. Commands that are in flight
+// fail, and every later call returns [net.ErrClosed].
+func (pool *Pool) Close() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.closed {
+		return nil
+	}
+	pool.closed = true
+	close(pool.done)
+
+	var errs []error
+	for _, client := range pool.clients {
+		if err := client.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	pool.open -= len(pool.idle)
+	pool.idle = nil
+	return errors.Join(errs...)
+}
+
+// Stats returns the sum of the counters of all connections of the pool.
+func (pool *Pool) Stats() Stats {
+	pool.mu.Lock()
+	clients := slices.Clone(pool.clients)
+	pool.mu.Unlock()
+
+	sum := Stats{Commands: make(map[MessageType]CommandStats)}
+	for _, client := range clients {
+		stats := client.Stats()
+		for command, s := range stats.Commands {
+			total := sum.Commands[command]
+			total.Requests += s.Requests
+			total.Errors += s.Errors
+			total.Duration += s.Duration
+			total.Wait += s.Wait
+			total.Coalesced += s.Coalesced
+			sum.Commands[command] = total
+		}
+		sum.Reconnects += stats.Reconnects
+		sum.QueueDepth += stats.QueueDepth
+	}
+	return sum
+}
+
+// GetPlayerList is like [Client.GetPlayerList].
+func (pool *Pool) GetPlayerList() ([]Player, error) {
+	return pool.GetPlayerListContext(context.Background())
+}
+
+// GetPlayerListContext is like [Client.GetPlayerListContext].
+func (pool *Pool) GetPlayerListContext(ctx context.Context) (players []Player, err error) {
+	err = pool.do(ctx, GetPlayerList, func(client *Client) error {
+		players, err = client.GetPlayerListContext(ctx)
+		return err
+	})
+	return players, err
+}
+
+// Announce is like [Client.Announce].
+func (pool *Pool) Announce(message string) error {
+	return pool.AnnounceContext(context.Background(), message)
+}
+
+// AnnounceContext is like [Client.AnnounceContext].
+func (pool *Pool) AnnounceContext(ctx context.Context, message string) error {
+	return pool.do(ctx, Announce, func(client *Client) error {
+		return client.AnnounceContext(ctx, message)
+	})
+}
+
+// SendDirectMessage is like [Client.SendDirectMessage].
+func (pool *Pool) SendDirectMessage(playerID PlayerID, message string) error {
+	return pool.SendDirectMessageContext(context.Background(), playerID, message)
+}
+
+// SendDirectMessageContext is like [Client.SendDirectMessageContext].
+func (pool *Pool) SendDirectMessageContext(ctx context.Context, playerID PlayerID, message string) error {
+	return pool.do(ctx, DirectMessage, func(client *Client) error {
+		return client.SendDirectMessageContext(ctx, playerID, message)
+	})
+}
+
+// GetPlayerData is like [Client.GetPlayerData].
+func (pool *Pool) GetPlayerData() ([]Player, error) {
+	return pool.GetPlayerDataContext(context.Background())
+}
+
+// GetPlayerDataContext is like [Client.GetPlayerDataContext].
+func (pool *Pool) GetPlayerDataContext(ctx context.Context) (players []Player, err error) {
+	err = pool.do(ctx, GetPlayerData, func(client *Client) error {
+		players, err = client.GetPlayerDataContext(ctx)
+		return err
+	})
+	return players, err
+}
+
+// GetPlayerDataWithTimestamp is like [Client.GetPlayerDataWithTimestamp].
+func (pool *Pool) GetPlayerDataWithTimestamp() ([]Player, time.Time, error) {
+	return pool.GetPlayerDataWithTimestampContext(context.Background())
+}
+
+// GetPlayerDataWithTimestampContext is like
+// [Client.GetPlayerDataWithTimestampContext].
+func (pool *Pool) GetPlayerDataWithTimestampContext(ctx context.Context) (players []Player, timestamp time.Time, err error) {
+	err = pool.do(ctx, GetPlayerData, func(client *Client) error {
+		players, timestamp, err = client.GetPlayerDataWithTimestampContext(ctx)
+		return err
+	})
+	return players, timestamp, err
+}
+
+// GetPlayerDataLenient is like [Client.GetPlayerDataLenient].
+func (pool *Pool) GetPlayerDataLenient() ([]Player, []*PlayerDataError, error) {
+	return pool.GetPlayerDataLenientContext(context.Background())
+}
+
+// GetPlayerDataLenientContext is like [Client.GetPlayerDataLenientContext].
+func (pool *Pool) GetPlayerDataLenientContext(ctx context.Context) (players []Player, skipped []*PlayerDataError, err error) {
+	err = pool.do(ctx, GetPlayerData, func(client *Client) error {
+		players, skipped, err = client.GetPlayerDataLenientContext(ctx)
+		return err
+	})
+	return players, skipped, err
+}
+
+// GetServerDetails is like [Client.GetServerDetails].
+func (pool *Pool) GetServerDetails() (*ServerDetails, error) {
+	return pool.GetServerDetailsContext(context.Background())
+}
+
+// GetServerDetailsContext is like [Client.GetServerDetailsContext].
+func (pool *Pool) GetServerDetailsContext(ctx context.Context) (details *ServerDetails, err error) {
+	err = pool.do(ctx, GetServerDetails, func(client *Client) error {
+		details, err = client.GetServerDetailsContext(ctx)
+		return err
+	})
+	return details, err
+}
+
+// WipeCorpses is like [Client.WipeCorpses].
+func (pool *Pool) WipeCorpses() error {
+	return pool.WipeCorpsesContext(context.Background())
+}
+
+// WipeCorpsesContext is like [Client.WipeCorpsesContext].
+func (pool *Pool) WipeCorpsesContext(ctx context.Context) error {
+	return pool.do(ctx, WipeCorpses, func(client *Client) error {
+		return client.WipeCorpsesContext(ctx)
+	})
+}
+
+// UpdatePlayables is like [Client.UpdatePlayables].
+func (pool *Pool) UpdatePlayables(classes []DinoClass) error {
+	return pool.UpdatePlayablesContext(context.Background(), classes)
+}
+
+// UpdatePlayablesContext is like [Client.UpdatePlayablesContext].
+func (pool *Pool) UpdatePlayablesContext(ctx context.Context, classes []DinoClass) error {
+	return pool.do(ctx, UpdatePlayables, func(client *Client) error {
+		return client.UpdatePlayablesContext(ctx, classes)
+	})
+}
+
+// KickPlayer is like [Client.KickPlayer].
+func (pool *Pool) KickPlayer(playerID PlayerID, reason string) error {
+	return pool.KickPlayerContext(context.Background(), playerID, reason)
+}
+
+// KickPlayerContext is like [Client.KickPlayerContext].
+func (pool *Pool) KickPlayerContext(ctx context.Context, playerID PlayerID, reason string) error {
+	return pool.do(ctx, KickPlayer, func(client *Client) error {
+		return client.KickPlayerContext(ctx, playerID, reason)
+	})
+}
+
+// BanPlayer is like [Client.BanPlayer].
+func (pool *Pool) BanPlayer(playerID PlayerID, reason string, duration time.Duration) error {
+	return pool.BanPlayerContext(context.Background(), playerID, reason, duration)
+}
+
+// BanPlayerContext is like [Client.BanPlayerContext].
+func (pool *Pool) BanPlayerContext(ctx context.Context, playerID PlayerID, reason string, duration time.Duration) error {
+	return pool.do(ctx, BanPlayer, func(client *Client) error {
+		return client.BanPlayerContext(ctx, playerID, reason, duration)
+	})
+}
+
+// Save is like [Client.Save].
+func (pool *Pool) Save() error {
+	return pool.SaveContext(context.Background())
+}
+
+// SaveContext is like [Client.SaveContext].
+func (pool *Pool) SaveContext(ctx context.Context) error {
+	return pool.do(ctx, Save, func(client *Client) error {
+		return client.SaveContext(ctx)
+	})
+}
+
+// ToggleWhitelist is like [Client.ToggleWhitelist].
+func (pool *Pool) ToggleWhitelist() (bool, error) {
+	return pool.ToggleWhitelistContext(context.Background())
+}
+
+// ToggleWhitelistContext is like [Client.ToggleWhitelistContext].
+func (pool *Pool) ToggleWhitelistContext(ctx context.Context) (bool, error) {
+	return pool.toggle(ctx, ToggleWhitelist, (*Client).ToggleWhitelistContext)
+}
+
+// AddWhitelistID is like [Client.AddWhitelistID].
+func (pool *Pool) AddWhitelistID(playerID ...PlayerID) error {
+	return pool.AddWhitelistIDContext(context.Background(), playerID...)
+}
+
+// AddWhitelistIDContext is like [Client.AddWhitelistIDContext].
+func (pool *Pool) AddWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error {
+	return pool.do(ctx, AddWhitelistID, func(client *Client) error {
+		return client.AddWhitelistIDContext(ctx, playerID...)
+	})
+}
+
+// RemoveWhitelistID is like [Client.RemoveWhitelistID].
+func (pool *Pool) RemoveWhitelistID(playerID ...PlayerID) error {
+	return pool.RemoveWhitelistIDContext(context.Background(), playerID...)
+}
+
+// RemoveWhitelistIDContext is like [Client.RemoveWhitelistIDContext].
+func (pool *Pool) RemoveWhitelistIDContext(ctx context.Context, playerID ...PlayerID) error {
+	return pool.do(ctx, RemoveWhitelistID, func(client *Client) error {
+		return client.RemoveWhitelistIDContext(ctx, playerID...)
+	})
+}
+
+// ToggleGlobalChat is like [Client.ToggleGlobalChat].
+func (pool *Pool) ToggleGlobalChat() (bool, error) {
+	return pool.ToggleGlobalChatContext(context.Background())
+}
+
+// ToggleGlobalChatContext is like [Client.ToggleGlobalChatContext].
+func (pool *Pool) ToggleGlobalChatContext(ctx context.Context) (bool, error) {
+	return pool.toggle(ctx, ToggleGlobalChat, (*Client).ToggleGlobalChatContext)
+}
+
+// ToggleHumans is like [Client.ToggleHumans].
+func (pool *Pool) ToggleHumans() (bool, error) {
+	return pool.ToggleHumansContext(context.Background())
+}
+
+// ToggleHumansContext is like [Client.ToggleHumansContext].
+func (pool *Pool) ToggleHumansContext(ctx context.Context) (bool, error) {
+	return pool.toggle(ctx, ToggleHumans, (*Client).ToggleHumansContext)
+}
+
+// ToggleAI is like [Client.ToggleAI].
+func (pool *Pool) ToggleAI() (bool, error) {
+	return pool.ToggleAIContext(context.Background())
+}
+
+// ToggleAIContext is like [Client.ToggleAIContext].
+func (pool *Pool) ToggleAIContext(ctx context.Context) (bool, error) {
+	return pool.toggle(ctx, ToggleAI, (*Client).ToggleAIContext)
+}
+
+// DisableAIClasses is like [Client.DisableAIClasses].
+func (pool *Pool) DisableAIClasses(classes []AIClass) error {
+	return pool.DisableAIClassesContext(context.Background(), classes)
+}
+
+// DisableAIClassesContext is like [Client.DisableAIClassesContext].
+func (pool *Pool) DisableAIClassesContext(ctx context.Context, classes []AIClass) error {
+	return pool.do(ctx, DisableAIClasses, func(client *Client) error {
+		return client.DisableAIClassesContext(ctx, classes)
+	})
+}
+
+// SetAIDensity is like [Client.SetAIDensity].
+func (pool *Pool) SetAIDensity(density float32) error {
+	return pool.SetAIDensityContext(context.Background(), density)
+}
+
+// SetAIDensityContext is like [Client.SetAIDensityContext].
+func (pool *Pool) SetAIDensityContext(ctx context.Context, density float32) error {
+	return pool.do(ctx, SetAIDensity, func(client *Client) error {
+		return client.SetAIDensityContext(ctx, density)
+	})
+}
+
+// SetWhitelist is like [Client.SetWhitelist].
+func (pool *Pool) SetWhitelist(on bool) (bool, error) {
+	return pool.SetWhitelistContext(context.Background(), on)
+}
+
+// SetWhitelistContext is like [Client.SetWhitelistContext].
+func (pool *Pool) SetWhitelistContext(ctx context.Context, on bool) (bool, error) {
+	return pool.set(ctx, ToggleWhitelist, (*Client).SetWhitelistContext, on)
+}
+
+// SetGlobalChat is like [Client.SetGlobalChat].
+func (pool *Pool) SetGlobalChat(on bool) (bool, error) {
+	return pool.SetGlobalChatContext(context.Background(), on)
+}
+
+// SetGlobalChatContext is like [Client.SetGlobalChatContext].
+func (pool *Pool) SetGlobalChatContext(ctx context.Context, on bool) (bool, error) {
+	return pool.set(ctx, ToggleGlobalChat, (*Client).SetGlobalChatContext, on)
+}
+
+// SetHumans is like [Client.SetHumans].
+func (pool *Pool) SetHumans(on bool) (bool, error) {
+	return pool.SetHumansContext(context.Background(), on)
+}
+
+// SetHumansContext is like [Client.SetHumansContext].
+func (pool *Pool) SetHumansContext(ctx context.Context, on bool) (bool, error) {
+	return pool.set(ctx, ToggleHumans, (*Client).SetHumansContext, on)
+}
+
+// SetAI is like [Client.SetAI].
+func (pool *Pool) SetAI(on bool) (bool, error) {
+	return pool.SetAIContext(context.Background(), on)
+}
+
+// SetAIContext is like [Client.SetAIContext].
+func (pool *Pool) SetAIContext(ctx context.Context, on bool) (bool, error) {
+	return pool.set(ctx, ToggleAI, (*Client).SetAIContext, on)
+}
+
+// ExecCommand is like [Client.ExecCommand].
+func (pool *Pool) ExecCommand(command byte, params ...string) (string, error) {
+	return pool.ExecCommandContext(context.Background(), command, params...)
+}
+
+// ExecCommandContext is like [Client.ExecCommandContext].
+func (pool *Pool) ExecCommandContext(ctx context.Context, command byte, params ...string) (res string, err error) {
+	err = pool.do(ctx, command, func(client *Client) error {
+		res, err = client.ExecCommandContext(ctx, command, params...)
+		return err
+	})
+	return res, err
+}
+
+// TakeSnapshot is like [Client.TakeSnapshot].
+func (pool *Pool) TakeSnapshot(ctx context.Context) (*Snapshot, error) {
+	players, err := pool.GetPlayerListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spawned, serverTime, err := pool.GetPlayerDataWithTimestampContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{time.Now(), serverTime, players, spawned}, nil
+}
+
+func (pool *Pool) toggle(ctx context.Context, command MessageType, toggle func(*Client, context.Context) (bool, error)) (on bool, err error) {
+	err = pool.do(ctx, command, func(client *Client) error {
+		on, err = toggle(client, ctx)
+		return err
+	})
+	return on, err
+}
+
+func (pool *Pool) set(ctx context.Context, command MessageType, set func(*Client, context.Context, bool) (bool, error), on bool) (changed bool, err error) {
+	err = pool.do(ctx, command, func(client *Client) error {
+		changed, err = set(client, ctx, on)
+		return err
+	})
+	return changed, err
+}
@@ -0,0 +1,366 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+// newPool starts a fake server and returns a pool of size connections to
+// it.
+func newPool(t *testing.T, size int, opts ...rcon.Option) (*rcontest.Server, *rcon.Pool) {
+	t.Helper()
+
+	server := rcontest.NewServer("password")
+	t.Cleanup(server.Close)
+
+	pool, err := rcon.NewPool(context.Background(), server.Addr, "password", size, opts...)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return server, pool
+}
+
+// openAll makes the pool open all size connections by running admin
+// commands on all of them at once.
+func openAll(t *testing.T, server *rcontest.Server, pool *rcon.Pool, size int) {
+	t.Helper()
+
+	server.SetDelay(50 * time.Millisecond)
+	defer server.SetDelay(0)
+
+	var wg sync.WaitGroup
+	for range size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Announce("hello"); err != nil {
+				t.Errorf("Announce: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// busyPoll keeps one connection busy with a poll during the check.
+		busyPoll bool
+		cutoff   time.Duration
+		want     uint64
+	}{
+		{name: "idle connection", size: 1, cutoff: 0, want: 1},
+		{name: "recently used", size: 1, cutoff: -time.Hour, want: 0},
+		{name: "all idle connections", size: 3, cutoff: 0, want: 3},
+		{name: "not the last free connection", size: 2, busyPoll: true, cutoff: 0, want: 0},
+		{name: "next to a poll", size: 3, busyPoll: true, cutoff: 0, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pool := newPool(t, tt.size)
+			openAll(t, server, pool, tt.size)
+
+			if tt.busyPoll {
+				server.SetDelay(200 * time.Millisecond)
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					pool.GetPlayerData()
+				}()
+				defer func() { <-done }()
+				// Wait for the poll to take its connection.
+				time.Sleep(50 * time.Millisecond)
+				server.SetDelay(0)
+			}
+
+			pool.CheckIdle(time.Now().Add(tt.cutoff))
+
+			if got := pool.Stats().Commands[rcon.GetPlayerList].Requests; got != tt.want {
+				t.Errorf("checked %d connections, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPool(t *testing.T) {
+	server := rcontest.NewServer("password")
+	defer server.Close()
+	down := rcontest.NewServer("password")
+	down.Close()
+
+	tests := []struct {
+		name     string
+		addr     string
+		password string
+		size     int
+	}{
+		{"size zero", server.Addr, "password", 0},
+		{"wrong password", server.Addr, "wrong", 2},
+		{"unreachable", down.Addr, "password", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := rcon.NewPool(context.Background(), tt.addr, tt.password, tt.size, fastReconnect)
+			if err == nil {
+				pool.Close()
+				t.Fatal("NewPool returned no error")
+			}
+		})
+	}
+}
+
+func TestPoolPolls(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		size  int
+		polls int
+		// rounds is the number of delays that the slowest poll takes,
+		// because polls never use the last connection.
+		rounds int
+		// kickWaits reports whether a kick has to wait for a poll.
+		kickWaits bool
+	}{
+		{name: "single connection", size: 1, polls: 1, rounds: 1, kickWaits: true},
+		{name: "one poll", size: 2, polls: 1, rounds: 1, kickWaits: false},
+		{name: "polls wait for each other", size: 2, polls: 2, rounds: 2, kickWaits: false},
+		{name: "polls side by side", size: 3, polls: 2, rounds: 1, kickWaits: false},
+		{name: "more polls than connections", size: 3, polls: 3, rounds: 2, kickWaits: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pool := newPool(t, tt.size)
+			openAll(t, server, pool, tt.size)
+
+			server.SetDelay(delay)
+			start := time.Now()
+			runPolls(t, pool, tt.polls)
+			if rounds := int(time.Since(start) / delay); rounds != tt.rounds {
+				t.Errorf("polls took %v, want %d rounds of %v", time.Since(start), tt.rounds, delay)
+			}
+
+			// A kick does not wait for the polls, unless there is only one
+			// connection.
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				runPolls(t, pool, tt.polls)
+			}()
+			defer func() { <-done }()
+			time.Sleep(delay / 4)
+			server.SetDelay(0)
+
+			kickStart := time.Now()
+			if err := pool.KickPlayer(alice.ID, "AFK"); err != nil {
+				t.Fatalf("KickPlayer: %v", err)
+			}
+			if waited := time.Since(kickStart) > delay/2; waited != tt.kickWaits {
+				t.Errorf("kick took %v, want waiting: %v", time.Since(kickStart), tt.kickWaits)
+			}
+		})
+	}
+}
+
+// runPolls runs n GetPlayerData at once and waits for them.
+func runPolls(t *testing.T, pool *rcon.Pool, n int) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.GetPlayerData(); err != nil {
+				t.Errorf("GetPlayerData: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPoolPriority(t *testing.T) {
+	tests := []struct {
+		name string
+		// queue lists the commands in the order in which they start to
+		// wait for the only connection.
+		queue []string
+		want  []string
+	}{
+		{
+			name:  "highest first",
+			queue: []string{"poll", "announce", "kick"},
+			want:  []string{"kick", "announce", "poll"},
+		},
+		{
+			name:  "oldest first",
+			queue: []string{"announce", "kick", "save", "ban"},
+			want:  []string{"kick", "ban", "announce", "save"},
+		},
+	}
+
+	run := map[string]func(pool *rcon.Pool) error{
+		"poll":     func(pool *rcon.Pool) error { _, err := pool.GetPlayerList(); return err },
+		"announce": func(pool *rcon.Pool) error { return pool.Announce("hello") },
+		"save":     func(pool *rcon.Pool) error { return pool.Save() },
+		"kick":     func(pool *rcon.Pool) error { return pool.KickPlayer(alice.ID, "AFK") },
+		"ban":      func(pool *rcon.Pool) error { return pool.BanPlayer(bob.ID, "griefing", 0) },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pool := newPool(t, 1)
+
+			// Keep the connection busy while the commands queue up.
+			server.SetDelay(100 * time.Millisecond)
+			busy := make(chan error)
+			go func() { busy <- pool.WipeCorpses() }()
+			time.Sleep(20 * time.Millisecond)
+			server.SetDelay(0)
+
+			var mu sync.Mutex
+			var got []string
+			var wg sync.WaitGroup
+			for _, name := range tt.queue {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := run[name](pool); err != nil {
+						t.Errorf("%s: %v", name, err)
+					}
+					mu.Lock()
+					got = append(got, name)
+					mu.Unlock()
+				}()
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if err := <-busy; err != nil {
+				t.Fatalf("WipeCorpses: %v", err)
+			}
+			wg.Wait()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commands ran in the order %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolReconnect(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(pool *rcon.Pool) error
+		wantErr bool
+	}{
+		{
+			name:    "idempotent",
+			run:     func(pool *rcon.Pool) error { return pool.AddWhitelistID(alice.ID) },
+			wantErr: false,
+		},
+		{
+			// The command may have reached the server, so it is not sent
+			// again. The connection is replaced for the next command.
+			name:    "not idempotent",
+			run:     func(pool *rcon.Pool) error { return pool.Announce("hello") },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pool := newPool(t, 1)
+			server.CloseClientConnections()
+
+			if err := tt.run(pool); (err != nil) != tt.wantErr {
+				t.Fatalf("first command after the connection broke returned %v, want error: %v", err, tt.wantErr)
+			}
+			if err := tt.run(pool); err != nil {
+				t.Fatalf("second command after the connection broke: %v", err)
+			}
+			if got := pool.Stats().Reconnects; got != 1 {
+				t.Errorf("Stats().Reconnects = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	server, pool := newPool(t, 1)
+
+	// One command is in flight and another one waits when the pool is
+	// closed.
+	server.SetDelay(200 * time.Millisecond)
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- pool.Announce("hello") }()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range 2 {
+		if err := <-errs; err == nil {
+			t.Error("a command succeeded although the pool was closed")
+		}
+	}
+
+	if err := pool.Announce("hello"); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Announce after Close returned %v, want %v", err, net.ErrClosed)
+	}
+	if err := pool.Close(); err != nil {
+		t.Errorf("second Close returned %v", err)
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	server, pool := newPool(t, 3)
+	openAll(t, server, pool, 3)
+
+	for range 2 {
+		if _, err := pool.GetPlayerList(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := pool.Stats()
+	if got := stats.Commands[rcon.Announce].Requests; got != 3 {
+		t.Errorf("Announce requests = %d, want 3", got)
+	}
+	if got := stats.Commands[rcon.GetPlayerList].Requests; got != 2 {
+		t.Errorf("GetPlayerList requests = %d, want 2", got)
+	}
+	if got := stats.Commands[rcon.Announce].Duration; got < 3*50*time.Millisecond {
+		t.Errorf("Announce duration = %v, want the sum of all connections", got)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A Priority decides which of the waiting commands is sent first.
+type Priority int
+
+const (
+	// PriorityLow is the default for polling commands: GetPlayerList,
+	// GetPlayerData and GetServerDetails.
+	PriorityLow Priority = iota
+	// PriorityNormal is the default for all commands that have no other
+	// priority, e.g. Announce and the toggles.
+	PriorityNormal
+	// PriorityHigh is the default for KickPlayer and BanPlayer.
+	PriorityHigh
+)
+
+func defaultPriority(command MessageType) Priority {
+	switch command {
+	case KickPlayer, BanPlayer:
+		return PriorityHigh
+	case GetPlayerList, GetPlayerData, GetServerDetails:
+		return PriorityLow
+	default:
+		return PriorityNormal
+	}
+}
+
+// A QueuePolicy configures the command queue of a client. See [WithQueue].
+type QueuePolicy struct {
+	// RateLimits sets the minimum time between the start of two commands
+	// of the same type, e.g. {Announce: time.Second}. Commands without an
+	// entry are not limited.
+	RateLimits map[MessageType]time.Duration
+
+	// Priorities replaces the default priorities of commands.
+	Priorities map[MessageType]Priority
+}
+
+func (policy *QueuePolicy) priority(command MessageType) Priority {
+	prio, ok := policy.Priorities[command]
+	if !ok {
+		return defaultPriority(command)
+	}
+	return min(max(prio, PriorityLow), PriorityHigh)
+}
+
+// WithQueue makes the client queue commands instead of sending them in
+// the order in which they are called.
+//
+// Whenever the connection is free, the client sends the waiting command
+// with the highest [Priority], or the oldest of those with the same
+// priority. A command whose rate limit has not yet passed waits and lets
+// other commands go first.
+//
+// While a GetPlayerList, GetPlayerData or GetServerDetails is waiting,
+// calls of the same command do not queue another request, but share the
+// response of the waiting one.
+//
+// The time that commands wait is reported by [Client.Stats].
+func WithQueue(policy QueuePolicy) Option {
+	return func(client *Client) {
+		policy.RateLimits = maps.Clone(policy.RateLimits)
+		policy.Priorities = maps.Clone(policy.Priorities)
+		client.queue = &commandQueue{
+			client:   client,
+			policy:   policy,
+			lastSent: make(map[MessageType]time.Time),
+		}
+	}
+}
+
+// A commandQueue orders the commands of a client. It sends one command
+// at a time, each from its own goroutine.
+type commandQueue struct {
+	client *Client
+	policy QueuePolicy
+
+	mu       sync.Mutex
+	pending  [PriorityHigh + 1][]*queuedCommand
+	depth    int
+	busy     bool
+	lastSent map[MessageType]time.Time
+	timer    *time.Timer
+}
+
+// A queuedCommand is a request and the callers that wait for its
+// response.
+type queuedCommand struct {
+	command MessageType
+	msg     []byte
+	queued  time.Time
+
+	// callers is the number of calls waiting for the response. When
+	// all of them have given up, the request is cancelled.
+	callers int
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	done chan struct{}
+	res  []byte
+	err  error
+}
+
+// isCoalescable reports whether waiting requests of command can share a
+// response.
+func isCoalescable(command MessageType) bool {
+	switch command {
+	case GetPlayerList, GetPlayerData, GetServerDetails:
+		return true
+	default:
+		return false
+	}
+}
+
+// exec queues a request and waits for its response.
+func (queue *commandQueue) exec(ctx context.Context, command MessageType, msg []byte) ([]byte, error) {
+	queue.mu.Lock()
+	prio := queue.policy.priority(command)
+	cmd := queue.join(prio, command, msg)
+	if cmd == nil {
+		cmdCtx, cancel := context.WithCancel(context.Background())
+		cmd = &queuedCommand{
+			command: command,
+			msg:     msg,
+			queued:  time.Now(),
+			callers: 1,
+			ctx:     cmdCtx,
+			cancel:  cancel,
+			done:    make(chan struct{}),
+		}
+		queue.pending[prio] = append(queue.pending[prio], cmd)
+		queue.depth++
+		queue.schedule()
+	}
+	queue.mu.Unlock()
+
+	select {
+	case <-cmd.done:
+		return cmd.res, cmd.err
+	case <-ctx.Done():
+		queue.leave(prio, cmd)
+		return nil, ctx.Err()
+	}
+}
+
+// join returns a waiting request that is identical to msg, if it may be
+// shared. The caller must hold queue.mu.
+func (queue *commandQueue) join(prio Priority, command MessageType, msg []byte) *queuedCommand {
+	if !isCoalescable(command) {
+		return nil
+	}
+	for _, cmd := range queue.pending[prio] {
+		if bytes.Equal(cmd.msg, msg) {
+			cmd.callers++
+			queue.client.stats.coalesced(command)
+			return cmd
+		}
+	}
+	return nil
+}
+
+// leave removes a caller that gave up waiting.
+func (queue *commandQueue) leave(prio Priority, cmd *queuedCommand) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	cmd.callers--
+	if cmd.callers > 0 {
+		return
+	}
+	if !cmd.started {
+		queue.pending[prio] = slices.DeleteFunc(queue.pending[prio], func(c *queuedCommand) bool { return c == cmd })
+		queue.depth--
+	}
+	cmd.cancel()
+}
+
+// schedule starts the next command if the connection is free. If every
+// waiting command is held back by its rate limit, schedule runs again
+// when the first of them may be sent. The caller must hold queue.mu.
+func (queue *commandQueue) schedule() {
+	if queue.busy {
+		return
+	}
+
+	now := time.Now()
+	var wake time.Time
+	for prio := PriorityHigh; prio >= PriorityLow; prio-- {
+		for i, cmd := range queue.pending[prio] {
+			limit, limited := queue.policy.RateLimits[cmd.command]
+			if next := queue.lastSent[cmd.command].Add(limit); limited && next.After(now) {
+				if wake.IsZero() || next.Before(wake) {
+					wake = next
+				}
+				continue
+			}
+
+			queue.pending[prio] = slices.Delete(queue.pending[prio], i, i+1)
+			queue.depth--
+			queue.busy = true
+			cmd.started = true
+			if limited {
+				queue.lastSent[cmd.command] = now
+			}
+			go queue.run(cmd)
+			return
+		}
+	}
+
+	if !wake.IsZero() {
+		if queue.timer != nil {
+			queue.timer.Stop()
+		}
+		queue.timer = time.AfterFunc(wake.Sub(now), func() {
+			queue.mu.Lock()
+			defer queue.mu.Unlock()
+			queue.schedule()
+		})
+	}
+}
+
+// run sends a command and hands the response to its callers.
+func (queue *commandQueue) run(cmd *queuedCommand) {
+	client := queue.client
+	client.stats.waited(cmd.command, time.Since(cmd.queued))
+
+	if err := client.lock(cmd.ctx); err != nil {
+		cmd.err = err
+	} else {
+		cmd.res, cmd.err = client.roundTrip(cmd.ctx, cmd.msg, isIdempotent(cmd.command))
+		client.unlock()
+	}
+	cmd.cancel()
+	close(cmd.done)
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.busy = false
+	queue.schedule()
+}
+
+// len returns the number of waiting commands.
+func (queue *commandQueue) len() int {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	return queue.depth
+}
@@ -0,0 +1,298 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+// queueCommands are the commands that the queue tests refer to by name.
+var queueCommands = map[string]func(ctx context.Context, client *rcon.Client) error{
+	"players": func(ctx context.Context, client *rcon.Client) error {
+		_, err := client.GetPlayerListContext(ctx)
+		return err
+	},
+	"details": func(ctx context.Context, client *rcon.Client) error {
+		_, err := client.GetServerDetailsContext(ctx)
+		return err
+	},
+	"announce": func(ctx context.Context, client *rcon.Client) error {
+		return client.AnnounceContext(ctx, "hello")
+	},
+	"save": func(ctx context.Context, client *rcon.Client) error {
+		return client.SaveContext(ctx)
+	},
+	"kick": func(ctx context.Context, client *rcon.Client) error {
+		return client.KickPlayerContext(ctx, alice.ID, "AFK")
+	},
+	"ban": func(ctx context.Context, client *rcon.Client) error {
+		return client.BanPlayerContext(ctx, bob.ID, "griefing", 0)
+	},
+}
+
+// busy keeps the connection of client busy for delay, so that the
+// commands that follow queue up. The returned function waits until the
+// connection is free again.
+func busy(t *testing.T, server *rcontest.Server, client *rcon.Client, delay time.Duration) func() {
+	t.Helper()
+
+	server.SetDelay(delay)
+	done := make(chan error, 1)
+	go func() { done <- client.WipeCorpses() }()
+	time.Sleep(20 * time.Millisecond)
+	server.SetDelay(0)
+
+	return func() {
+		t.Helper()
+		if err := <-done; err != nil {
+			t.Fatalf("WipeCorpses: %v", err)
+		}
+	}
+}
+
+// runQueued runs the named commands in this order, each a little after
+// the previous one, and returns the order in which they finished.
+func runQueued(t *testing.T, client *rcon.Client, names []string) []string {
+	t.Helper()
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := queueCommands[name](context.Background(), client); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}()
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+	return order
+}
+
+func TestQueuePriority(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy rcon.QueuePolicy
+		queue  []string
+		want   []string
+	}{
+		{
+			name:  "default priorities",
+			queue: []string{"players", "announce", "kick"},
+			want:  []string{"kick", "announce", "players"},
+		},
+		{
+			name:  "oldest first",
+			queue: []string{"save", "announce", "ban", "kick"},
+			want:  []string{"ban", "kick", "save", "announce"},
+		},
+		{
+			name: "custom priorities",
+			policy: rcon.QueuePolicy{Priorities: map[rcon.MessageType]rcon.Priority{
+				rcon.KickPlayer:    rcon.PriorityLow,
+				rcon.GetPlayerList: rcon.PriorityHigh,
+			}},
+			queue: []string{"kick", "announce", "players"},
+			want:  []string{"players", "announce", "kick"},
+		},
+		{
+			name: "out of range",
+			policy: rcon.QueuePolicy{Priorities: map[rcon.MessageType]rcon.Priority{
+				rcon.Announce: rcon.PriorityHigh + 10,
+				rcon.Save:     rcon.PriorityLow - 10,
+			}},
+			queue: []string{"save", "players", "kick", "announce"},
+			want:  []string{"kick", "announce", "save", "players"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, rcon.WithQueue(tt.policy))
+
+			wait := busy(t, server, client, 100*time.Millisecond)
+			got := runQueued(t, client, tt.queue)
+			wait()
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commands ran in the order %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueRateLimit(t *testing.T) {
+	const limit = 100 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		queue []string
+		want  []string
+	}{
+		{
+			// The second announcement waits for its rate limit and lets
+			// the save go first.
+			name:  "others go first",
+			queue: []string{"announce", "announce", "save"},
+			want:  []string{"announce", "save", "announce"},
+		},
+		{
+			name:  "priority does not skip the limit",
+			queue: []string{"ban", "ban", "players"},
+			want:  []string{"ban", "players", "ban"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := connect(t, rcon.WithQueue(rcon.QueuePolicy{
+				RateLimits: map[rcon.MessageType]time.Duration{rcon.Announce: limit, rcon.BanPlayer: limit},
+			}))
+
+			start := time.Now()
+			got := runQueued(t, client, tt.queue)
+			if elapsed := time.Since(start); elapsed < limit {
+				t.Errorf("commands took %v, want at least the rate limit of %v", elapsed, limit)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("commands ran in the order %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueCoalescing(t *testing.T) {
+	tests := []struct {
+		name    string
+		command rcon.MessageType
+		queue   []string
+		// requests and coalesced are counted for command.
+		requests  uint64
+		coalesced uint64
+	}{
+		{"player list", rcon.GetPlayerList, []string{"players", "players", "players"}, 1, 2},
+		{"server details", rcon.GetServerDetails, []string{"details", "announce", "details"}, 1, 1},
+		{"announce", rcon.Announce, []string{"announce", "announce"}, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, rcon.WithQueue(rcon.QueuePolicy{}))
+
+			wait := busy(t, server, client, 100*time.Millisecond)
+			runQueued(t, client, tt.queue)
+			wait()
+
+			stats := client.Stats().Commands[tt.command]
+			if stats.Requests != tt.requests || stats.Coalesced != tt.coalesced {
+				t.Errorf("%d requests and %d coalesced, want %d and %d", stats.Requests, stats.Coalesced, tt.requests, tt.coalesced)
+			}
+		})
+	}
+}
+
+func TestQueueStats(t *testing.T) {
+	server, client := connect(t, rcon.WithQueue(rcon.QueuePolicy{}))
+
+	wait := busy(t, server, client, 100*time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runQueued(t, client, []string{"announce", "save"})
+	}()
+	time.Sleep(30 * time.Millisecond)
+
+	if depth := client.Stats().QueueDepth; depth != 2 {
+		t.Errorf("QueueDepth = %d while two commands wait, want 2", depth)
+	}
+	wait()
+	<-done
+
+	stats := client.Stats()
+	if stats.QueueDepth != 0 {
+		t.Errorf("QueueDepth = %d after all commands ran, want 0", stats.QueueDepth)
+	}
+	if wait := stats.Commands[rcon.Announce].Wait; wait < 30*time.Millisecond {
+		t.Errorf("Announce waited %v, want the time the connection was busy", wait)
+	}
+}
+
+func TestQueueCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		// callers wait for the same GetPlayerList; those with cancel
+		// give up while it is queued.
+		cancel []bool
+		// requests is the number of GetPlayerList that are sent.
+		requests uint64
+	}{
+		{"single caller", []bool{true}, 0},
+		{"one of two callers", []bool{true, false}, 1},
+		{"all callers", []bool{true, true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t, rcon.WithQueue(rcon.QueuePolicy{}))
+			wait := busy(t, server, client, 100*time.Millisecond)
+
+			var wg sync.WaitGroup
+			for _, cancel := range tt.cancel {
+				ctx := context.Background()
+				if cancel {
+					var stop context.CancelFunc
+					ctx, stop = context.WithTimeout(ctx, 20*time.Millisecond)
+					defer stop()
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := client.GetPlayerListContext(ctx)
+					if cancel && err != context.DeadlineExceeded {
+						t.Errorf("canceled caller got error %v, want %v", err, context.DeadlineExceeded)
+					} else if !cancel && err != nil {
+						t.Errorf("GetPlayerList: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+			wait()
+
+			stats := client.Stats()
+			if got := stats.Commands[rcon.GetPlayerList].Requests; got != tt.requests {
+				t.Errorf("%d requests sent, want %d", got, tt.requests)
+			}
+			if stats.QueueDepth != 0 {
+				t.Errorf("QueueDepth = %d, want 0", stats.QueueDepth)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package rcontest provides a fake The Isle Evrima RCON server for tests.
+//
+// The server speaks the same framing as a real server and keeps a simulated
+// world with players, a whitelist and the toggles from
+// [theislercon.ServerDetails]. Commands sent by a client change that world
+// and the responses are formatted like the ones of a real server.
+package rcontest
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// TimestampMode determines whether responses start with a timestamp.
+type TimestampMode int
+
+const (
+	// TimestampAlways prefixes every response with a timestamp.
+	TimestampAlways TimestampMode = iota
+	// TimestampNever sends responses without a timestamp.
+	TimestampNever
+	// TimestampAlternate prefixes every other response with a timestamp,
+	// which is closer to what real servers do.
+	TimestampAlternate
+)
+
+// A Server is a fake RCON server listening on a local port.
+type Server struct {
+	// Addr is the address the server listens on, in the form "host:port".
+	// It is set by Start.
+	Addr string
+	// Password is the RCON password that clients must authenticate with.
+	Password string
+	// Timestamps determines whether responses start with a timestamp.
+	Timestamps TimestampMode
+	// Now returns the time used for timestamps. It defaults to [time.Now].
+	Now func() time.Time
+
+	tcp tcpServer
+
+	mu        sync.Mutex
+	world     World
+	responses int
+	delay     time.Duration
+}
+
+// NewServer starts and returns a new Server with a default world.
+// The caller should call Close when finished, to shut it down.
+func NewServer(password string) *Server {
+	server := NewUnstartedServer(password)
+	server.Start()
+	return server
+}
+
+// NewUnstartedServer returns a new Server with a default world, but
+// doesn't start it. This allows changing the fields of the server before
+// clients connect.
+func NewUnstartedServer(password string) *Server {
+	return &Server{
+		Password: password,
+		Now:      time.Now,
+		world:    DefaultWorld(),
+	}
+}
+
+// Start starts a server from NewUnstartedServer.
+func (server *Server) Start() {
+	server.Addr = server.tcp.start(server.handle)
+}
+
+// Close shuts down the server and waits until all connections are closed.
+func (server *Server) Close() {
+	server.tcp.close()
+}
+
+// CloseClientConnections closes all open client connections, but keeps
+// the server running. This simulates a server restart.
+func (server *Server) CloseClientConnections() {
+	server.tcp.closeConns()
+}
+
+// Update calls fn with the world of the server. The world must not be
+// used after fn returns.
+func (server *Server) Update(fn func(world *World)) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	fn(&server.world)
+}
+
+// SetDelay makes the server wait for delay before it sends a response,
+// like a busy server does. Responses that are already being delayed are
+// not affected.
+func (server *Server) SetDelay(delay time.Duration) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.delay = delay
+}
+
+func (server *Server) handle(conn net.Conn) {
+	authenticated := false
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		msg := bytes.TrimRight(buf[:n], "\x00")
+		if len(msg) == 0 {
+			continue
+		}
+
+		var res string
+		switch msg[0] {
+		case rcon.Auth:
+			if string(msg[1:]) == server.Password {
+				authenticated = true
+				res = "Password Accepted"
+			} else {
+				res = "Incorrect Password"
+			}
+		case rcon.ExecCommand:
+			if !authenticated || len(msg) < 2 {
+				return
+			}
+			res, err = server.exec(msg[1], string(msg[2:]))
+			if err != nil {
+				return
+			}
+		default:
+			return
+		}
+
+		server.mu.Lock()
+		delay := server.delay
+		server.mu.Unlock()
+		time.Sleep(delay)
+
+		// Terminating the response, like some server versions do, saves
+		// the client from waiting for the idle gap.
+		_, err = conn.Write(append([]byte(res), 0))
+		if err != nil {
+			return
+		}
+	}
+}
+
+var errUnknownCommand = errors.New("unknown command")
+
+// exec applies a command to the world and formats the response.
+func (server *Server) exec(command byte, params string) (string, error) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	var args []string
+	if params != "" {
+		args = strings.Split(params, ",")
+	}
+
+	name, content, err := server.world.exec(command, params, args)
+	if err != nil {
+		return "", err
+	}
+
+	return server.prefix() + name + content, nil
+}
+
+// prefix returns the timestamp that the next response starts with.
+func (server *Server) prefix() string {
+	server.responses++
+	switch server.Timestamps {
+	case TimestampNever:
+		return ""
+	case TimestampAlternate:
+		if server.responses%2 == 0 {
+			return ""
+		}
+	}
+	return "[" + server.Now().Format("2006.01.02-15.04.05") + "] "
+}
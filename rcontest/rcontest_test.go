@@ -0,0 +1,306 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rcontest_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+const (
+	aliceID rcon.PlayerID = "76561198000000001"
+	bobID   rcon.PlayerID = "76561198000000002"
+)
+
+var (
+	alice = rcon.Player{
+		ID:        aliceID,
+		Name:      "Alice",
+		Location:  rcon.Location{X: 1.5, Y: -2.25, Z: 300},
+		DinoClass: rcon.Troodon,
+		Growth:    75,
+		Health:    90,
+		Stamina:   80,
+		Hunger:    70,
+		Thirst:    60,
+	}
+	// bob is in the class selection screen.
+	bob = rcon.Player{ID: bobID, Name: "Bob"}
+)
+
+// connect starts a server with alice and bob and returns an authenticated
+// client.
+func connect(t *testing.T) (*rcontest.Server, *rcon.Client) {
+	t.Helper()
+
+	server := rcontest.NewServer("password")
+	t.Cleanup(server.Close)
+	server.Update(func(world *rcontest.World) {
+		world.Players = []rcon.Player{alice, bob}
+	})
+
+	client, err := rcon.Connect(server.Addr)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	return server, client
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(client *rcon.Client) error
+		// want changes the default world to what it must look like after
+		// the command.
+		want func(world *rcontest.World)
+	}{
+		{
+			name: "Announce",
+			run:  func(c *rcon.Client) error { return c.Announce("Restart soon") },
+			want: func(w *rcontest.World) { w.Announcements = []string{"Restart soon"} },
+		},
+		{
+			name: "SendDirectMessage",
+			run:  func(c *rcon.Client) error { return c.SendDirectMessage(aliceID, "Hi, Alice") },
+			want: func(w *rcontest.World) {
+				// The client replaces the comma, which separates arguments.
+				w.DirectMessages = []rcontest.DirectMessage{{PlayerID: aliceID, Message: "Hi\u201a Alice"}}
+			},
+		},
+		{
+			name: "KickPlayer",
+			run:  func(c *rcon.Client) error { return c.KickPlayer(bobID, "AFK") },
+			want: func(w *rcontest.World) {
+				w.Kicks = []rcontest.Kick{{PlayerID: bobID, Reason: "AFK"}}
+				w.Players = []rcon.Player{alice}
+			},
+		},
+		{
+			name: "BanPlayer",
+			run:  func(c *rcon.Client) error { return c.BanPlayer(aliceID, "Cheating", time.Hour) },
+			want: func(w *rcontest.World) {
+				w.Bans = []rcontest.Ban{{PlayerID: aliceID, Reason: "Cheating", Minutes: 60}}
+				w.Players = []rcon.Player{bob}
+			},
+		},
+		{
+			name: "AddWhitelistID",
+			run:  func(c *rcon.Client) error { return c.AddWhitelistID(aliceID, bobID) },
+			want: func(w *rcontest.World) { w.Whitelist = []rcon.PlayerID{aliceID, bobID} },
+		},
+		{
+			name: "RemoveWhitelistID",
+			run: func(c *rcon.Client) error {
+				if err := c.AddWhitelistID(aliceID, bobID); err != nil {
+					return err
+				}
+				return c.RemoveWhitelistID(aliceID)
+			},
+			want: func(w *rcontest.World) { w.Whitelist = []rcon.PlayerID{bobID} },
+		},
+		{
+			name: "ToggleWhitelist",
+			run:  func(c *rcon.Client) error { _, err := c.ToggleWhitelist(); return err },
+			want: func(w *rcontest.World) { w.Details.Whitelist = true },
+		},
+		{
+			name: "ToggleGlobalChat",
+			run:  func(c *rcon.Client) error { _, err := c.ToggleGlobalChat(); return err },
+			want: func(w *rcontest.World) { w.Details.EnableGlobalChat = false },
+		},
+		{
+			name: "ToggleHumans",
+			run:  func(c *rcon.Client) error { _, err := c.ToggleHumans(); return err },
+			want: func(w *rcontest.World) { w.Details.EnableHumans = true },
+		},
+		{
+			name: "ToggleAI",
+			run:  func(c *rcon.Client) error { _, err := c.ToggleAI(); return err },
+			want: func(w *rcontest.World) { w.Details.SpawnAI = false },
+		},
+		{
+			name: "UpdatePlayables",
+			run:  func(c *rcon.Client) error { return c.UpdatePlayables([]rcon.DinoClass{rcon.Troodon, rcon.Stegosaurus}) },
+			want: func(w *rcontest.World) { w.Playables = []rcon.DinoClass{rcon.Troodon, rcon.Stegosaurus} },
+		},
+		{
+			name: "DisableAIClasses",
+			run:  func(c *rcon.Client) error { return c.DisableAIClasses([]rcon.AIClass{rcon.Boar, rcon.Deer}) },
+			want: func(w *rcontest.World) { w.DisabledAIClasses = []rcon.AIClass{rcon.Boar, rcon.Deer} },
+		},
+		{
+			name: "SetAIDensity",
+			run:  func(c *rcon.Client) error { return c.SetAIDensity(0.5) },
+			want: func(w *rcontest.World) { w.AIDensity = 0.5 },
+		},
+		{
+			name: "Save",
+			run:  func(c *rcon.Client) error { return c.Save() },
+			want: func(w *rcontest.World) { w.Saves = 1 },
+		},
+		{
+			name: "WipeCorpses",
+			run:  func(c *rcon.Client) error { return c.WipeCorpses() },
+			want: func(w *rcontest.World) { w.CorpseWipes = 1 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t)
+
+			if err := tt.run(client); err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+
+			want := rcontest.DefaultWorld()
+			want.Players = []rcon.Player{alice, bob}
+			tt.want(&want)
+
+			var got rcontest.World
+			server.Update(func(world *rcontest.World) {
+				got = *world
+			})
+			if !reflect.DeepEqual(normalize(got), normalize(want)) {
+				t.Errorf("world after command:\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+// normalize makes empty and nil slices of world equal.
+func normalize(world rcontest.World) rcontest.World {
+	world.Players = append([]rcon.Player{}, world.Players...)
+	world.Whitelist = append([]rcon.PlayerID{}, world.Whitelist...)
+	world.Playables = append([]rcon.DinoClass{}, world.Playables...)
+	world.DisabledAIClasses = append([]rcon.AIClass{}, world.DisabledAIClasses...)
+	world.Announcements = append([]string{}, world.Announcements...)
+	world.DirectMessages = append([]rcontest.DirectMessage{}, world.DirectMessages...)
+	world.Kicks = append([]rcontest.Kick{}, world.Kicks...)
+	world.Bans = append([]rcontest.Ban{}, world.Bans...)
+	return world
+}
+
+func TestQueries(t *testing.T) {
+	_, client := connect(t)
+
+	players, err := client.GetPlayerList()
+	if err != nil {
+		t.Fatalf("GetPlayerList: %v", err)
+	}
+	wantList := []rcon.Player{{ID: aliceID, Name: "Alice"}, {ID: bobID, Name: "Bob"}}
+	if !reflect.DeepEqual(players, wantList) {
+		t.Errorf("GetPlayerList() = %+v, want %+v", players, wantList)
+	}
+
+	players, err = client.GetPlayerData()
+	if err != nil {
+		t.Fatalf("GetPlayerData: %v", err)
+	}
+	if want := []rcon.Player{alice}; !reflect.DeepEqual(players, want) {
+		t.Errorf("GetPlayerData() = %+v, want %+v", players, want)
+	}
+
+	details, err := client.GetServerDetails()
+	if err != nil {
+		t.Fatalf("GetServerDetails: %v", err)
+	}
+	want := rcontest.DefaultWorld().Details
+	want.CurrentPlayers = 2
+	want.Timestamp = details.Timestamp
+	if !reflect.DeepEqual(*details, want) {
+		t.Errorf("GetServerDetails() = %+v, want %+v", *details, want)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  error
+	}{
+		{"password", nil},
+		{"wrong", rcon.ErrIncorrectPassword},
+		{"", rcon.ErrIncorrectPassword},
+	}
+
+	server := rcontest.NewServer("password")
+	defer server.Close()
+
+	for _, tt := range tests {
+		client, err := rcon.Connect(server.Addr)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		if err := client.Auth(tt.password); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Auth(%q): got error %v, want %v", tt.password, err, tt.wantErr)
+		}
+		client.Close()
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		mode rcontest.TimestampMode
+		want []time.Time
+	}{
+		{"always", rcontest.TimestampAlways, []time.Time{now, now, now}},
+		{"never", rcontest.TimestampNever, []time.Time{{}, {}, {}}},
+		{"alternate", rcontest.TimestampAlternate, []time.Time{now, {}, now}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rcontest.NewUnstartedServer("password")
+			server.Timestamps = tt.mode
+			server.Now = func() time.Time { return now }
+			server.Start()
+			defer server.Close()
+
+			client, err := rcon.Connect(server.Addr)
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+			if err := client.Auth("password"); err != nil {
+				t.Fatalf("Auth: %v", err)
+			}
+
+			for i, want := range tt.want {
+				_, got, err := client.GetPlayerDataWithTimestamp()
+				if err != nil {
+					t.Fatalf("GetPlayerDataWithTimestamp: %v", err)
+				}
+				if !got.Equal(want) {
+					t.Errorf("response %d: timestamp %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
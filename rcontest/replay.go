@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rcontest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// A ReplayServer serves the responses of a capture recorded with
+// [rcon.Recorder].
+//
+// The server expects the requests of the capture in the recorded order,
+// across all connections. Each request is answered with the recorded
+// response. Authentication requests match regardless of the password,
+// because the password is not part of the capture. If a request does not
+// match, the server closes the connection and reports the mismatch in Err.
+type ReplayServer struct {
+	// Addr is the address the server listens on, in the form "host:port".
+	Addr string
+	// KeepTiming makes the server wait as long as the original server took
+	// before it sends a response.
+	KeepTiming bool
+
+	tcp tcpServer
+
+	mu        sync.Mutex
+	exchanges []rcon.Exchange
+	next      int
+	err       error
+}
+
+// NewReplayServer starts and returns a new ReplayServer that serves the
+// given exchanges. The caller should call Close when finished, to shut it
+// down.
+func NewReplayServer(exchanges []rcon.Exchange) *ReplayServer {
+	server := &ReplayServer{exchanges: exchanges}
+	server.Addr = server.tcp.start(server.handle)
+	return server
+}
+
+// NewReplayServerFromFile reads a capture file and serves it like
+// [NewReplayServer].
+func NewReplayServerFromFile(name string) (*ReplayServer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	exchanges, err := rcon.ReadCapture(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayServer(exchanges), nil
+}
+
+// Close shuts down the server and waits until all connections are closed.
+func (server *ReplayServer) Close() {
+	server.tcp.close()
+}
+
+// Err returns the first request that did not match the capture.
+func (server *ReplayServer) Err() error {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.err
+}
+
+// Remaining returns the number of exchanges that have not been replayed.
+func (server *ReplayServer) Remaining() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return len(server.exchanges) - server.next
+}
+
+func (server *ReplayServer) handle(conn net.Conn) {
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		exchange, ok := server.match(buf[:n])
+		if !ok {
+			return
+		}
+
+		if server.KeepTiming {
+			time.Sleep(exchange.Duration)
+		}
+
+		// A failed exchange usually means that the server never responded
+		// or dropped the connection.
+		if exchange.Err != "" && len(exchange.Response) == 0 {
+			return
+		}
+
+		// The client strips the NULL byte from the recorded responses.
+		// Terminating them saves it from waiting for the idle gap.
+		_, err = conn.Write(append(bytes.Clone(exchange.Response), 0))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// match returns the next exchange, if req is its request.
+func (server *ReplayServer) match(req []byte) (rcon.Exchange, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.err != nil {
+		return rcon.Exchange{}, false
+	}
+
+	if server.next >= len(server.exchanges) {
+		server.err = fmt.Errorf("rcontest: unexpected request %q after end of capture", req)
+		return rcon.Exchange{}, false
+	}
+
+	exchange := server.exchanges[server.next]
+	if !requestsMatch(exchange.Request, req) {
+		server.err = fmt.Errorf("rcontest: request %d: expected %q, got %q", server.next+1, exchange.Request, req)
+		return rcon.Exchange{}, false
+	}
+
+	server.next++
+	return exchange, true
+}
+
+func requestsMatch(recorded, req []byte) bool {
+	req = bytes.TrimRight(req, "\x00")
+	if len(recorded) > 0 && recorded[0] == rcon.Auth {
+		return len(req) > 0 && req[0] == rcon.Auth
+	}
+	return bytes.Equal(bytes.TrimRight(recorded, "\x00"), req)
+}
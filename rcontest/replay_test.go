@@ -0,0 +1,210 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rcontest_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+var (
+	authExchange = rcon.Exchange{Request: []byte{rcon.Auth}, Response: []byte("Password Accepted")}
+	listExchange = rcon.Exchange{
+		Request:  []byte{rcon.ExecCommand, rcon.GetPlayerList},
+		Response: []byte("PlayerList\n76561198000000001,Alice,,\n"),
+	}
+)
+
+func TestReplayServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		exchanges []rcon.Exchange
+		// run sends the requests. Its error is compared with wantErr.
+		run           func(t *testing.T, client *rcon.Client) error
+		wantErr       bool
+		wantServerErr bool
+		wantRemaining int
+	}{
+		{
+			name:      "replayed",
+			exchanges: []rcon.Exchange{authExchange, listExchange},
+			run: func(t *testing.T, client *rcon.Client) error {
+				players, err := client.GetPlayerList()
+				if err == nil && !reflect.DeepEqual(players, []rcon.Player{{ID: aliceID, Name: "Alice"}}) {
+					t.Errorf("GetPlayerList() = %+v", players)
+				}
+				return err
+			},
+		},
+		{
+			name:          "not all requests sent",
+			exchanges:     []rcon.Exchange{authExchange, listExchange, listExchange},
+			run:           func(t *testing.T, client *rcon.Client) error { _, err := client.GetPlayerList(); return err },
+			wantRemaining: 1,
+		},
+		{
+			name:          "unexpected request",
+			exchanges:     []rcon.Exchange{authExchange, listExchange},
+			run:           func(t *testing.T, client *rcon.Client) error { return client.Save() },
+			wantErr:       true,
+			wantServerErr: true,
+			wantRemaining: 1,
+		},
+		{
+			name:      "request after end of capture",
+			exchanges: []rcon.Exchange{authExchange},
+			run: func(t *testing.T, client *rcon.Client) error {
+				_, err := client.GetPlayerList()
+				return err
+			},
+			wantErr:       true,
+			wantServerErr: true,
+		},
+		{
+			name: "recorded error",
+			exchanges: []rcon.Exchange{authExchange, {
+				Request: []byte{rcon.ExecCommand, rcon.GetPlayerList},
+				Err:     "read: connection reset by peer",
+			}},
+			run:     func(t *testing.T, client *rcon.Client) error { _, err := client.GetPlayerList(); return err },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rcontest.NewReplayServer(tt.exchanges)
+			defer server.Close()
+
+			client, err := rcon.Connect(server.Addr)
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+			// The password is not part of the capture.
+			if err := client.Auth("any password"); err != nil {
+				t.Fatalf("Auth: %v", err)
+			}
+
+			if err := tt.run(t, client); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if err := server.Err(); (err != nil) != tt.wantServerErr {
+				t.Errorf("server.Err() = %v, want error: %v", err, tt.wantServerErr)
+			}
+			if got := server.Remaining(); got != tt.wantRemaining {
+				t.Errorf("server.Remaining() = %d, want %d", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestReplayKeepTiming(t *testing.T) {
+	slow := listExchange
+	slow.Duration = 150 * time.Millisecond
+
+	for _, keepTiming := range []bool{false, true} {
+		server := rcontest.NewReplayServer([]rcon.Exchange{authExchange, slow})
+		server.KeepTiming = keepTiming
+
+		client, err := rcon.Connect(server.Addr)
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		if err := client.Auth("password"); err != nil {
+			t.Fatalf("Auth: %v", err)
+		}
+
+		start := time.Now()
+		if _, err := client.GetPlayerList(); err != nil {
+			t.Errorf("GetPlayerList: %v", err)
+		}
+		if took := time.Since(start); (took >= slow.Duration) != keepTiming {
+			t.Errorf("KeepTiming %v: response took %v", keepTiming, took)
+		}
+
+		client.Close()
+		server.Close()
+	}
+}
+
+// TestReplayRecording records a session with the fake server and replays
+// it from the file.
+func TestReplayRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.txt")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := rcontest.NewServer("password")
+	server.Update(func(world *rcontest.World) {
+		world.Players = []rcon.Player{alice, bob}
+	})
+	client, err := rcon.Connect(server.Addr, rcon.WithRecorder(rcon.NewRecorder(f)))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	wantData, err := client.GetPlayerData()
+	if err != nil {
+		t.Fatalf("GetPlayerData: %v", err)
+	}
+	wantDetails, err := client.GetServerDetails()
+	if err != nil {
+		t.Fatalf("GetServerDetails: %v", err)
+	}
+	client.Close()
+	server.Close()
+	f.Close()
+
+	replay, err := rcontest.NewReplayServerFromFile(path)
+	if err != nil {
+		t.Fatalf("NewReplayServerFromFile: %v", err)
+	}
+	defer replay.Close()
+
+	client, err = rcon.Connect(replay.Addr)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+	if err := client.Auth("another password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	if data, err := client.GetPlayerData(); err != nil || !reflect.DeepEqual(data, wantData) {
+		t.Errorf("GetPlayerData() = %+v, %v, want %+v", data, err, wantData)
+	}
+	if details, err := client.GetServerDetails(); err != nil || !reflect.DeepEqual(details, wantDetails) {
+		t.Errorf("GetServerDetails() = %+v, %v, want %+v", details, err, wantDetails)
+	}
+	if err := replay.Err(); err != nil {
+		t.Error(err)
+	}
+	if n := replay.Remaining(); n != 0 {
+		t.Errorf("%d exchanges were not replayed", n)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rcontest
+
+import (
+	"net"
+	"sync"
+)
+
+// tcpServer accepts connections on a local port and keeps track of them.
+type tcpServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// start listens on a random local port and calls handle for every
+// connection in a new goroutine. The connection is closed after handle
+// returns. start returns the address of the listener.
+func (tcp *tcpServer) start(handle func(net.Conn)) string {
+	if tcp.listener != nil {
+		panic("rcontest: server already started")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("rcontest: failed to listen on a port: " + err.Error())
+	}
+	tcp.listener = listener
+	tcp.conns = make(map[net.Conn]struct{})
+
+	tcp.wg.Add(1)
+	go tcp.serve(handle)
+
+	return listener.Addr().String()
+}
+
+func (tcp *tcpServer) serve(handle func(net.Conn)) {
+	defer tcp.wg.Done()
+	for {
+		conn, err := tcp.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		tcp.mu.Lock()
+		tcp.conns[conn] = struct{}{}
+		tcp.mu.Unlock()
+
+		tcp.wg.Add(1)
+		go func() {
+			defer tcp.wg.Done()
+			defer func() {
+				tcp.mu.Lock()
+				delete(tcp.conns, conn)
+				tcp.mu.Unlock()
+				conn.Close()
+			}()
+			handle(conn)
+		}()
+	}
+}
+
+// close stops listening and waits until all connections are closed.
+func (tcp *tcpServer) close() {
+	tcp.listener.Close()
+	tcp.closeConns()
+	tcp.wg.Wait()
+}
+
+func (tcp *tcpServer) closeConns() {
+	tcp.mu.Lock()
+	defer tcp.mu.Unlock()
+	for conn := range tcp.conns {
+		conn.Close()
+	}
+}
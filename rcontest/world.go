@@ -0,0 +1,287 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rcontest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+// World is the simulated state of a server.
+type World struct {
+	// Details are reported by GetServerDetails. CurrentPlayers is ignored
+	// and computed from Players instead. The keys of Details.Extra are
+	// reported after the known keys, in sorted order.
+	Details rcon.ServerDetails
+
+	// Players are all connected players. Players without a DinoClass are
+	// in the class selection screen and are not part of PlayerData.
+	Players []rcon.Player
+
+	Whitelist         []rcon.PlayerID
+	Playables         []rcon.DinoClass
+	DisabledAIClasses []rcon.AIClass
+	AIDensity         float64
+
+	// The following fields record the commands that have no lasting
+	// effect on the world.
+	Announcements  []string
+	DirectMessages []DirectMessage
+	Kicks          []Kick
+	Bans           []Ban
+	Saves          int
+	CorpseWipes    int
+}
+
+// DirectMessage is a message that was sent with the DirectMessage command.
+type DirectMessage struct {
+	PlayerID rcon.PlayerID
+	Message  string
+}
+
+// Kick is a player that was kicked with the KickPlayer command.
+type Kick struct {
+	PlayerID rcon.PlayerID
+	Reason   string
+}
+
+// Ban is a player that was banned with the BanPlayer command.
+type Ban struct {
+	PlayerID rcon.PlayerID
+	Reason   string
+	Minutes  int // Zero means permanently
+}
+
+// DefaultWorld returns an empty world with typical server settings.
+func DefaultWorld() World {
+	return World{
+		Details: rcon.ServerDetails{
+			Name:                           "rcontest",
+			Map:                            "Gateway",
+			MaxPlayers:                     100,
+			EnableMutations:                true,
+			SpawnAI:                        true,
+			AllowRecordingGameplay:         true,
+			UseRegionSpawning:              true,
+			UseRegionSpawnCooldown:         true,
+			RegionSpawnCooldownTimeSeconds: 30,
+			DayLengthMinutes:               45,
+			NightLengthMinutes:             20,
+			EnableGlobalChat:               true,
+		},
+		Playables: slices.Clone(rcon.AllClasses[:]),
+		AIDensity: 1,
+	}
+}
+
+// Player returns the connected player with the given ID.
+func (world *World) Player(playerID rcon.PlayerID) (*rcon.Player, bool) {
+	for i := range world.Players {
+		if world.Players[i].ID == playerID {
+			return &world.Players[i], true
+		}
+	}
+	return nil, false
+}
+
+// AddPlayer connects a player to the server.
+func (world *World) AddPlayer(player rcon.Player) {
+	world.Players = append(world.Players, player)
+}
+
+// RemovePlayer disconnects a player from the server.
+func (world *World) RemovePlayer(playerID rcon.PlayerID) bool {
+	for i := range world.Players {
+		if world.Players[i].ID == playerID {
+			world.Players = slices.Delete(world.Players, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// exec applies a command and returns the name and content of the response.
+func (world *World) exec(command byte, params string, args []string) (string, string, error) {
+	switch command {
+	case rcon.Announce:
+		world.Announcements = append(world.Announcements, params)
+		return "Announce", " " + params, nil
+	case rcon.DirectMessage:
+		if len(args) < 2 {
+			return "DirectMessage", " Missing arguments", nil
+		}
+		message := strings.Join(args[1:], ",")
+		world.DirectMessages = append(world.DirectMessages, DirectMessage{rcon.PlayerID(args[0]), message})
+		return "DirectMessage", " " + message, nil
+	case rcon.GetServerDetails:
+		return "ServerDetails", world.formatServerDetails(), nil
+	case rcon.WipeCorpses:
+		world.CorpseWipes++
+		return "WipeCorpses", " Corpses wiped", nil
+	case rcon.UpdatePlayables:
+		world.Playables = world.Playables[:0]
+		for _, arg := range args {
+			world.Playables = append(world.Playables, rcon.DinoClass(arg))
+		}
+		return "UpdatePlayables", " Playables updated", nil
+	case rcon.KickPlayer:
+		if len(args) < 1 {
+			return "KickPlayer", " Missing arguments", nil
+		}
+		reason := strings.Join(args[1:], ",")
+		world.Kicks = append(world.Kicks, Kick{rcon.PlayerID(args[0]), reason})
+		world.RemovePlayer(rcon.PlayerID(args[0]))
+		return "KickPlayer", " " + args[0], nil
+	case rcon.BanPlayer:
+		// This is the argument order that Client.BanPlayer sends. It has
+		// not been verified against a real server.
+		if len(args) < 3 {
+			return "BanPlayer", " Invalid arguments", nil
+		}
+		minutes, err := strconv.Atoi(args[len(args)-1])
+		if err != nil || minutes < 0 {
+			return "BanPlayer", " Invalid duration", nil
+		}
+		reason := strings.Join(args[1:len(args)-1], ",")
+		world.Bans = append(world.Bans, Ban{rcon.PlayerID(args[0]), reason, minutes})
+		world.RemovePlayer(rcon.PlayerID(args[0]))
+		return "BanPlayer", " Banned " + args[0], nil
+	case rcon.GetPlayerList:
+		return "PlayerList", world.formatPlayerList(), nil
+	case rcon.Save:
+		world.Saves++
+		return "Save", " World saved", nil
+	case rcon.GetPlayerData:
+		return "PlayerData", world.formatPlayerData(), nil
+	case rcon.ToggleWhitelist:
+		world.Details.Whitelist = !world.Details.Whitelist
+		return "ToggleWhitelist", " Whitelist: " + formatOnOff(world.Details.Whitelist), nil
+	case rcon.AddWhitelistID:
+		for _, arg := range args {
+			id := rcon.PlayerID(arg)
+			if !slices.Contains(world.Whitelist, id) {
+				world.Whitelist = append(world.Whitelist, id)
+			}
+		}
+		return "AddWhitelistID", " " + params, nil
+	case rcon.RemoveWhitelistID:
+		world.Whitelist = slices.DeleteFunc(world.Whitelist, func(id rcon.PlayerID) bool {
+			return slices.Contains(args, string(id))
+		})
+		return "RemoveWhitelistID", " " + params, nil
+	case rcon.ToggleGlobalChat:
+		world.Details.EnableGlobalChat = !world.Details.EnableGlobalChat
+		return "ToggleGlobalChat", " Global chat: " + formatOnOff(world.Details.EnableGlobalChat), nil
+	case rcon.ToggleHumans:
+		world.Details.EnableHumans = !world.Details.EnableHumans
+		return "ToggleHumans", " Humans: " + formatOnOff(world.Details.EnableHumans), nil
+	case rcon.ToggleAI:
+		world.Details.SpawnAI = !world.Details.SpawnAI
+		return "ToggleAI", " AI: " + formatOnOff(world.Details.SpawnAI), nil
+	case rcon.DisableAIClasses:
+		world.DisabledAIClasses = world.DisabledAIClasses[:0]
+		for _, arg := range args {
+			world.DisabledAIClasses = append(world.DisabledAIClasses, rcon.AIClass(arg))
+		}
+		return "DisableAIClasses", " " + params, nil
+	case rcon.SetAIDensity:
+		density, err := strconv.ParseFloat(params, 64)
+		if err != nil {
+			return "AIDensity", " Invalid density", nil
+		}
+		world.AIDensity = density
+		return "AIDensity", " " + params, nil
+	default:
+		return "", "", errUnknownCommand
+	}
+}
+
+func (world *World) formatPlayerList() string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for _, player := range world.Players {
+		fmt.Fprintf(&sb, "%s,%s,,\n", player.ID, player.Name)
+	}
+	return sb.String()
+}
+
+func (world *World) formatPlayerData() string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for _, player := range world.Players {
+		if player.DinoClass == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "Name: %s, PlayerID: %s, Location: X=%.3f Y=%.3f Z=%.3f, Class: BP_%s_C, Growth: %.2f, Health: %.2f, Stamina: %.2f, Hunger: %.2f, Thirst: %.2f\n",
+			player.Name, player.ID,
+			player.Location.X, player.Location.Y, player.Location.Z,
+			player.DinoClass,
+			formatPercentage(player.Growth),
+			formatPercentage(player.Health),
+			formatPercentage(player.Stamina),
+			formatPercentage(player.Hunger),
+			formatPercentage(player.Thirst))
+	}
+	return sb.String()
+}
+
+func (world *World) formatServerDetails() string {
+	d := &world.Details
+	fields := []string{
+		"ServerName: " + d.Name,
+		"ServerPassword: " + d.Password,
+		"ServerMap: " + d.Map,
+		"ServerMaxPlayers: " + strconv.Itoa(d.MaxPlayers),
+		"ServerCurrentPlayers: " + strconv.Itoa(len(world.Players)),
+		"bEnableMutations: " + strconv.FormatBool(d.EnableMutations),
+		"bEnableHumans: " + strconv.FormatBool(d.EnableHumans),
+		"bServerPassword: " + strconv.FormatBool(d.Password != ""),
+		"bQueueEnabled: " + strconv.FormatBool(d.QueueEnabled),
+		"bServerWhitelist: " + strconv.FormatBool(d.Whitelist),
+		"bSpawnAI: " + strconv.FormatBool(d.SpawnAI),
+		"bAllowRecordingReplay: " + strconv.FormatBool(d.AllowRecordingGameplay),
+		"bUseRegionSpawning: " + strconv.FormatBool(d.UseRegionSpawning),
+		"bUseRegionSpawnCooldown: " + strconv.FormatBool(d.UseRegionSpawnCooldown),
+		"RegionSpawnCooldownTimeSeconds: " + strconv.Itoa(d.RegionSpawnCooldownTimeSeconds),
+		"ServerDayLengthMinutes: " + strconv.Itoa(d.DayLengthMinutes),
+		"ServerNightLengthMinutes: " + strconv.Itoa(d.NightLengthMinutes),
+		"bEnableGlobalChat: " + strconv.FormatBool(d.EnableGlobalChat),
+	}
+	for _, key := range slices.Sorted(maps.Keys(d.Extra)) {
+		fields = append(fields, key+": "+d.Extra[key])
+	}
+	return "\n" + strings.Join(fields, ", ")
+}
+
+// formatPercentage turns a percentage into the fraction that the server
+// reports.
+func formatPercentage(p int8) float64 {
+	return float64(p) / 100
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
@@ -0,0 +1,189 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ReconnectPolicy controls how a [Client] replaces a broken connection.
+//
+// The zero value is a usable policy that retries forever, starting with
+// a delay of one second and doubling it up to 30 seconds.
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of times the client tries to connect
+	// before giving up. Zero means that there is no limit and only the
+	// context of the call can stop the client from trying.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit for the delay between two attempts.
+	MaxBackoff time.Duration
+}
+
+func (policy *ReconnectPolicy) initialBackoff() time.Duration {
+	if policy.InitialBackoff > 0 {
+		return policy.InitialBackoff
+	}
+	return time.Second
+}
+
+func (policy *ReconnectPolicy) maxBackoff() time.Duration {
+	if policy.MaxBackoff > 0 {
+		return policy.MaxBackoff
+	}
+	return 30 * time.Second
+}
+
+// EnableReconnect turns on the resilient mode of the client.
+//
+// In resilient mode, the client remembers the address it connected to and
+// the last password that was accepted by [Client.Auth]. When a request
+// fails because the connection broke (e.g. because the server restarted),
+// the next request first connects again and authenticates with the
+// remembered password.
+//
+// Requests that can safely be repeated, like [Client.GetPlayerList] or
+// [Client.GetServerDetails], are retried once on the new connection.
+// Other requests, like [Client.Announce] or the toggles, may already
+// have been executed by the server, so their error is returned to the
+// caller instead.
+//
+// Without resilient mode, the next request after the connection broke
+// makes a single attempt at connecting again and fails with [ErrBroken]
+// if that does not work. Nothing is retried.
+func (client *Client) EnableReconnect(policy ReconnectPolicy) {
+	client.sem <- struct{}{}
+	defer client.unlock()
+	client.reconnect = &policy
+}
+
+// redial replaces the current connection with a new, authenticated one.
+//
+// The caller must hold the lock.
+func (client *Client) redial(ctx context.Context) error {
+	policy := client.reconnect
+	backoff := policy.initialBackoff()
+
+	for attempt := 1; ; attempt++ {
+		err := client.reopen(ctx)
+		if err == nil {
+			client.logger.Info("rcon reconnected", "addr", client.addr, "attempt", attempt)
+			client.stats.reconnected()
+			client.broken = false
+			return nil
+		}
+
+		client.logger.Warn("rcon reconnect failed", "addr", client.addr, "attempt", attempt, "error", err)
+
+		if errors.Is(err, ErrIncorrectPassword) || errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return fmt.Errorf("%w: %v", ErrReconnectFailed, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		backoff = min(backoff*2, policy.maxBackoff())
+	}
+}
+
+// reconnectOnce replaces the broken connection of a client without a
+// [ReconnectPolicy]. It makes a single attempt, so that a server that is
+// gone is reported right away.
+//
+// The caller must hold the lock.
+func (client *Client) reconnectOnce(ctx context.Context) error {
+	err := client.reopen(ctx)
+	switch {
+	case err == nil:
+		client.logger.Info("rcon reconnected", "addr", client.addr, "attempt", 1)
+		client.stats.reconnected()
+		client.broken = false
+		return nil
+	case errors.Is(err, ErrIncorrectPassword) || errors.Is(err, net.ErrClosed):
+		return err
+	case ctx.Err() != nil:
+		return ctx.Err()
+	default:
+		return fmt.Errorf("%w: %v", ErrBroken, err)
+	}
+}
+
+// reopen makes one attempt at connecting and authenticating.
+func (client *Client) reopen(ctx context.Context) error {
+	conn, err := client.dialContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	client.connMu.Lock()
+	if client.closed {
+		client.connMu.Unlock()
+		conn.Close()
+		return net.ErrClosed
+	}
+	client.conn.Close()
+	client.conn = conn
+	client.unterminated = false
+	client.connMu.Unlock()
+
+	if client.password == "" {
+		return nil
+	}
+
+	msg := make([]byte, 0, 100)
+	msg = append(msg, Auth)
+	msg = append(msg, client.password...)
+
+	res, err := client.exchange(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if string(res) != "Password Accepted" {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+// isIdempotent reports whether sending a command twice has the same
+// effect as sending it once.
+func isIdempotent(command byte) bool {
+	switch command {
+	case GetServerDetails, GetPlayerList, GetPlayerData,
+		UpdatePlayables, AddWhitelistID, RemoveWhitelistID,
+		DisableAIClasses, SetAIDensity, Save:
+		return true
+	default:
+		return false
+	}
+}
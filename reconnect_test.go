@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+// errAny matches every error in the tables of this file.
+var errAny = errors.New("any error")
+
+func matchError(err, want error) bool {
+	if want == errAny {
+		return err != nil
+	}
+	return errors.Is(err, want)
+}
+
+func TestReconnect(t *testing.T) {
+	getPlayerList := func(ctx context.Context, client *rcon.Client) error {
+		_, err := client.GetPlayerListContext(ctx)
+		return err
+	}
+	announce := func(ctx context.Context, client *rcon.Client) error {
+		return client.AnnounceContext(ctx, "hello")
+	}
+	fast := rcon.ReconnectPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond}
+
+	tests := []struct {
+		name   string
+		policy *rcon.ReconnectPolicy
+		// enable uses EnableReconnect instead of WithReconnect.
+		enable bool
+		// stop shuts the server down instead of restarting it.
+		stop    bool
+		command func(ctx context.Context, client *rcon.Client) error
+		// wantErr is the error of the first command after the restart,
+		// wantNext the one of the command after that.
+		wantErr        error
+		wantNext       error
+		wantReconnects uint64
+	}{
+		{
+			name:           "idempotent command is retried",
+			policy:         &fast,
+			command:        getPlayerList,
+			wantReconnects: 1,
+		},
+		{
+			name:           "enabled after connecting",
+			policy:         &fast,
+			enable:         true,
+			command:        getPlayerList,
+			wantReconnects: 1,
+		},
+		{
+			name:           "other command is not retried",
+			policy:         &fast,
+			command:        announce,
+			wantErr:        errAny,
+			wantReconnects: 1,
+		},
+		{
+			name:           "without reconnect",
+			command:        getPlayerList,
+			wantErr:        errAny,
+			wantReconnects: 1,
+		},
+		{
+			name:     "without reconnect, server gone",
+			stop:     true,
+			command:  getPlayerList,
+			wantErr:  errAny,
+			wantNext: rcon.ErrBroken,
+		},
+		{
+			name:     "server gone",
+			policy:   &fast,
+			stop:     true,
+			command:  getPlayerList,
+			wantErr:  rcon.ErrReconnectFailed,
+			wantNext: rcon.ErrReconnectFailed,
+		},
+		{
+			name:     "retrying until the context is done",
+			policy:   &rcon.ReconnectPolicy{InitialBackoff: 10 * time.Millisecond},
+			stop:     true,
+			command:  getPlayerList,
+			wantErr:  context.DeadlineExceeded,
+			wantNext: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []rcon.Option
+			if tt.policy != nil && !tt.enable {
+				opts = append(opts, rcon.WithReconnect(*tt.policy))
+			}
+			server, client := connect(t, opts...)
+			if tt.enable {
+				client.EnableReconnect(*tt.policy)
+			}
+
+			if tt.stop {
+				server.Close()
+			} else {
+				server.CloseClientConnections()
+			}
+
+			for i, want := range []error{tt.wantErr, tt.wantNext} {
+				ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+				err := tt.command(ctx, client)
+				cancel()
+				if !matchError(err, want) {
+					t.Errorf("command %d: got error %v, want %v", i+1, err, want)
+				}
+			}
+
+			if got := client.Stats().Reconnects; got != tt.wantReconnects {
+				t.Errorf("%d reconnects, want %d", got, tt.wantReconnects)
+			}
+		})
+	}
+}
+
+// TestReconnectAuth checks that the client authenticates again with the
+// password that was accepted last.
+func TestReconnectAuth(t *testing.T) {
+	server, client := connect(t, rcon.WithReconnect(rcon.ReconnectPolicy{MaxAttempts: 1}))
+
+	server.CloseClientConnections()
+	if err := client.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var saves int
+	server.Update(func(world *rcontest.World) { saves = world.Saves })
+	if saves != 1 {
+		t.Errorf("server saved %d times, want 1", saves)
+	}
+}
@@ -19,12 +19,18 @@ along with this program.  If not, see <https://www.gnu.org/licenses/>.
 package theislercon
 
 import (
-	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/butt4cak3/theislercon/internal/parser"
 )
 
 type ServerDetails struct {
+	// Timestamp is the time of the server when it sent the details. It is
+	// zero if the server did not send a timestamp.
+	Timestamp time.Time
+
 	Name                           string
 	Password                       string
 	Map                            string
@@ -43,10 +49,69 @@ type ServerDetails struct {
 	DayLengthMinutes               int
 	NightLengthMinutes             int
 	EnableGlobalChat               bool
+
+	// Extra contains the keys that this library does not know (yet), with
+	// their values as sent by the server. Use the Extra methods to read
+	// them as other types.
+	Extra map[string]string
+}
+
+// ExtraString returns the value of an unknown key.
+func (details *ServerDetails) ExtraString(key string) (string, bool) {
+	value, ok := details.Extra[key]
+	return value, ok
 }
 
-func parseServerDetails(msg string) (*ServerDetails, error) {
-	response, err := parseResponse(msg, "ServerDetails")
+// ExtraInt returns the value of an unknown key as an integer. It returns
+// false if the key does not exist or its value is not an integer.
+func (details *ServerDetails) ExtraInt(key string) (int, bool) {
+	value, ok := details.Extra[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// ExtraFloat returns the value of an unknown key as a float. It returns
+// false if the key does not exist or its value is not a number.
+func (details *ServerDetails) ExtraFloat(key string) (float64, bool) {
+	value, ok := details.Extra[key]
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// ExtraBool returns the value of an unknown key as a bool. It returns
+// false as the second value if the key does not exist or its value is
+// neither "true" nor "false".
+func (details *ServerDetails) ExtraBool(key string) (bool, bool) {
+	switch details.Extra[key] {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// parseServerDetails parses the response to GetServerDetails.
+//
+// Keys that are not known are stored in Extra, unless strict is true. In
+// that case, they make parsing fail.
+func parseServerDetails(msg string, loc *time.Location, strict bool) (*ServerDetails, error) {
+	raw := msg
+
+	response, err := parseResponse(GetServerDetails, msg, "ServerDetails", loc)
 	if err != nil {
 		return nil, err
 	}
@@ -56,12 +121,13 @@ func parseServerDetails(msg string) (*ServerDetails, error) {
 	msg = parser.SkipWhitespace(msg)
 
 	details := new(ServerDetails)
+	details.Timestamp = response.Timestamp
 
 	for len(msg) > 0 {
 		m := msg
 		key, m, err := parseKey(m)
 		if err != nil {
-			return nil, err
+			return nil, newParseError(GetServerDetails, raw, parser.InField("key", err))
 		}
 
 		m = parser.SkipWhitespace(m)
@@ -104,11 +170,21 @@ func parseServerDetails(msg string) (*ServerDetails, error) {
 		case "bEnableGlobalChat":
 			details.EnableGlobalChat, m, err = parseBoolValue(m)
 		default:
-			return nil, fmt.Errorf("unknown key %s", key)
+			if strict {
+				e := parser.Expected("known key", msg)
+				e.Field = key
+				return nil, newParseError(GetServerDetails, raw, e)
+			}
+			var value string
+			value, m = parseExtraValue(m)
+			if details.Extra == nil {
+				details.Extra = make(map[string]string)
+			}
+			details.Extra[key] = strings.TrimSpace(value)
 		}
 
 		if err != nil {
-			return nil, err
+			return nil, newParseError(GetServerDetails, raw, parser.InField(key, err))
 		}
 
 		m = parser.SkipWhitespace(m)
@@ -127,7 +203,7 @@ func parseServerDetails(msg string) (*ServerDetails, error) {
 
 func parseKey(msg string) (string, string, error) {
 	pos := 0
-	for pos < len(msg) && parser.IsAsciiLetter(msg[pos]) {
+	for pos < len(msg) && (parser.IsAsciiLetter(msg[pos]) || parser.IsAsciiDigit(msg[pos]) || msg[pos] == '_') {
 		pos++
 	}
 	msg = parser.SkipWhitespace(msg)
@@ -144,22 +220,40 @@ func parseKey(msg string) (string, string, error) {
 
 func parseStringValue(msg string) (string, string) {
 	pos := 0
-	for len(msg) > 0 && msg[pos] != ',' {
+	for pos < len(msg) && msg[pos] != ',' {
 		pos++
 	}
 	return msg[:pos], msg[pos:]
 }
 
+// parseExtraValue parses the value of an unknown key. Unlike the known
+// string values, it may contain commas. It ends at the next ", Key:".
+func parseExtraValue(msg string) (string, string) {
+	for pos := 0; pos < len(msg); pos++ {
+		if msg[pos] == ',' && isKeyNext(msg[pos+1:]) {
+			return msg[:pos], msg[pos:]
+		}
+	}
+	return msg, ""
+}
+
+// isKeyNext reports whether msg starts with a key and its colon.
+func isKeyNext(msg string) bool {
+	msg = parser.SkipWhitespace(msg)
+	key, _, err := parseKey(msg)
+	return err == nil && key != ""
+}
+
 func parseIntValue(msg string) (int, string, error) {
 	return parser.ParseInt(msg)
 }
 
 func parseBoolValue(msg string) (bool, string, error) {
-	if msg[0:4] == "true" {
+	if strings.HasPrefix(msg, "true") {
 		return true, msg[4:], nil
-	} else if msg[0:5] == "false" {
+	} else if strings.HasPrefix(msg, "false") {
 		return false, msg[5:], nil
 	} else {
-		return false, "", fmt.Errorf("expected bool")
+		return false, "", parser.Expected("bool", msg)
 	}
 }
@@ -0,0 +1,73 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestServerDetailsExtra(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]string
+	}{
+		{"none", nil},
+		{"number", map[string]string{"MaxDinos": "200"}},
+		{"comma", map[string]string{"Motd": "Hello, world"}},
+		{"several commas", map[string]string{"Mods": "a,b, c ,d"}},
+		{"colon", map[string]string{"Rules": "Rule 1: be nice, Rule 2:no KOS"}},
+		{"several keys", map[string]string{"Amotd": "Hi, you", "Bflag": "true", "Cmods": "x, y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t)
+			server.Update(func(world *rcontest.World) {
+				world.Details.Extra = tt.extra
+			})
+
+			details, err := client.GetServerDetails()
+			if err != nil {
+				t.Fatalf("GetServerDetails: %v", err)
+			}
+			if !maps.Equal(details.Extra, tt.extra) {
+				t.Errorf("Extra = %q, want %q", details.Extra, tt.extra)
+			}
+			if want := rcontest.DefaultWorld().Details.Name; details.Name != want {
+				t.Errorf("Name = %q, want %q", details.Name, want)
+			}
+		})
+	}
+}
+
+func TestServerDetailsStrict(t *testing.T) {
+	server, client := connect(t, rcon.WithStrictServerDetails())
+	server.Update(func(world *rcontest.World) {
+		world.Details.Extra = map[string]string{"Motd": "Hello, world"}
+	})
+
+	var parseErr *rcon.ParseError
+	if _, err := client.GetServerDetails(); !errors.As(err, &parseErr) {
+		t.Errorf("GetServerDetails: got error %v, want a *ParseError", err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"fmt"
+)
+
+// The RCON protocol only has commands that toggle features. The setters
+// in this file read the current state from the server details first and
+// only toggle if the state differs from the desired one.
+//
+// Another admin may still toggle the same feature between the two
+// commands. In that case, the response of the toggle does not match the
+// desired state and the setter returns [ErrUnexpectedState].
+
+// SetWhitelist turns the whitelist on or off.
+//
+// It returns true if the state of the whitelist was changed.
+func (client *Client) SetWhitelist(on bool) (bool, error) {
+	return client.SetWhitelistContext(context.Background(), on)
+}
+
+// SetWhitelistContext is like [Client.SetWhitelist], but takes a context.
+func (client *Client) SetWhitelistContext(ctx context.Context, on bool) (bool, error) {
+	return client.setToggle(ctx, "whitelist", on,
+		func(details *ServerDetails) bool { return details.Whitelist },
+		client.ToggleWhitelistContext)
+}
+
+// SetGlobalChat turns the global chat on or off.
+//
+// It returns true if the state of the global chat was changed.
+func (client *Client) SetGlobalChat(on bool) (bool, error) {
+	return client.SetGlobalChatContext(context.Background(), on)
+}
+
+// SetGlobalChatContext is like [Client.SetGlobalChat], but takes a context.
+func (client *Client) SetGlobalChatContext(ctx context.Context, on bool) (bool, error) {
+	return client.setToggle(ctx, "global chat", on,
+		func(details *ServerDetails) bool { return details.EnableGlobalChat },
+		client.ToggleGlobalChatContext)
+}
+
+// SetHumans turns the humans feature on or off.
+//
+// It returns true if the state of the feature was changed.
+func (client *Client) SetHumans(on bool) (bool, error) {
+	return client.SetHumansContext(context.Background(), on)
+}
+
+// SetHumansContext is like [Client.SetHumans], but takes a context.
+func (client *Client) SetHumansContext(ctx context.Context, on bool) (bool, error) {
+	return client.setToggle(ctx, "humans", on,
+		func(details *ServerDetails) bool { return details.EnableHumans },
+		client.ToggleHumansContext)
+}
+
+// SetAI turns the spawning of AI on or off.
+//
+// It returns true if the state of AI spawning was changed.
+func (client *Client) SetAI(on bool) (bool, error) {
+	return client.SetAIContext(context.Background(), on)
+}
+
+// SetAIContext is like [Client.SetAI], but takes a context.
+func (client *Client) SetAIContext(ctx context.Context, on bool) (bool, error) {
+	return client.setToggle(ctx, "AI", on,
+		func(details *ServerDetails) bool { return details.SpawnAI },
+		client.ToggleAIContext)
+}
+
+func (client *Client) setToggle(
+	ctx context.Context,
+	name string,
+	on bool,
+	current func(*ServerDetails) bool,
+	toggle func(context.Context) (bool, error),
+) (bool, error) {
+	details, err := client.GetServerDetailsContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if current(details) == on {
+		return false, nil
+	}
+
+	state, err := toggle(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if state != on {
+		return true, fmt.Errorf("%w: %s is %s", ErrUnexpectedState, name, formatOnOff(state))
+	}
+
+	return true, nil
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"errors"
+	"testing"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+func TestSetters(t *testing.T) {
+	type setter struct {
+		name  string
+		set   func(client *rcon.Client, on bool) (bool, error)
+		state func(details *rcon.ServerDetails) *bool
+	}
+	setters := []setter{
+		{"SetWhitelist", (*rcon.Client).SetWhitelist, func(d *rcon.ServerDetails) *bool { return &d.Whitelist }},
+		{"SetGlobalChat", (*rcon.Client).SetGlobalChat, func(d *rcon.ServerDetails) *bool { return &d.EnableGlobalChat }},
+		{"SetHumans", (*rcon.Client).SetHumans, func(d *rcon.ServerDetails) *bool { return &d.EnableHumans }},
+		{"SetAI", (*rcon.Client).SetAI, func(d *rcon.ServerDetails) *bool { return &d.SpawnAI }},
+	}
+
+	tests := []struct {
+		name        string
+		initial     bool
+		on          bool
+		wantChanged bool
+	}{
+		{"turn on", false, true, true},
+		{"turn off", true, false, true},
+		{"already on", true, true, false},
+		{"already off", false, false, false},
+	}
+
+	for _, s := range setters {
+		for _, tt := range tests {
+			t.Run(s.name+"/"+tt.name, func(t *testing.T) {
+				server, client := connect(t)
+				server.Update(func(world *rcontest.World) {
+					*s.state(&world.Details) = tt.initial
+				})
+
+				changed, err := s.set(client, tt.on)
+				if err != nil {
+					t.Fatalf("%s(%v): %v", s.name, tt.on, err)
+				}
+				if changed != tt.wantChanged {
+					t.Errorf("%s(%v) = %v, want %v", s.name, tt.on, changed, tt.wantChanged)
+				}
+
+				var got bool
+				server.Update(func(world *rcontest.World) {
+					got = *s.state(&world.Details)
+				})
+				if got != tt.on {
+					t.Errorf("server state is %v, want %v", got, tt.on)
+				}
+			})
+		}
+	}
+}
+
+// TestSetterUnexpectedState checks that a toggle response that does not
+// match the requested state is reported.
+func TestSetterUnexpectedState(t *testing.T) {
+	server := rcontest.NewReplayServer([]rcon.Exchange{
+		{Request: []byte{rcon.Auth}, Response: []byte("Password Accepted")},
+		{Request: []byte{rcon.ExecCommand, rcon.GetServerDetails}, Response: []byte("ServerDetails\nbServerWhitelist: false")},
+		// Another admin turned the whitelist on in the meantime.
+		{Request: []byte{rcon.ExecCommand, rcon.ToggleWhitelist}, Response: []byte("ToggleWhitelist Whitelist: Off")},
+	})
+	defer server.Close()
+
+	client, err := rcon.Connect(server.Addr)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+	if err := client.Auth("password"); err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+
+	changed, err := client.SetWhitelist(true)
+	if !errors.Is(err, rcon.ErrUnexpectedState) {
+		t.Errorf("SetWhitelist(true): got error %v, want %v", err, rcon.ErrUnexpectedState)
+	}
+	if !changed {
+		t.Error("SetWhitelist(true) reported no change, but it toggled the whitelist")
+	}
+	if err := server.Err(); err != nil {
+		t.Error(err)
+	}
+}
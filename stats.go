@@ -0,0 +1,139 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// Stats are counters of the requests that a client has sent since it was
+// created. They are meant for monitoring, e.g. to export them as metrics.
+type Stats struct {
+	// Commands contains the counters of every command that was sent at
+	// least once. Auth requests are counted under [Auth].
+	Commands map[MessageType]CommandStats
+
+	// Reconnects is the number of times the client connected again after
+	// the connection broke.
+	Reconnects uint64
+
+	// QueueDepth is the number of commands that are waiting in the queue
+	// of the client. It is always zero without [WithQueue].
+	QueueDepth int
+}
+
+// CommandStats are the counters of one command.
+type CommandStats struct {
+	// Requests is the number of requests, including failed ones.
+	Requests uint64
+
+	// Errors is the number of requests that did not get a response, e.g.
+	// because of a timeout or a broken connection. Responses that could
+	// not be parsed are not counted.
+	Errors uint64
+
+	// Duration is the total time from sending the requests until their
+	// responses arrived or they failed. Waiting for other requests to
+	// finish is not included.
+	Duration time.Duration
+
+	// Wait is the total time that the requests spent in the queue before
+	// they were sent. It is always zero without [WithQueue].
+	Wait time.Duration
+
+	// Coalesced is the number of calls that did not send a request of
+	// their own, but shared the response of an identical queued request.
+	// They are not included in Requests.
+	Coalesced uint64
+}
+
+// Stats returns a snapshot of the counters of the client.
+func (client *Client) Stats() Stats {
+	var depth int
+	if client.queue != nil {
+		depth = client.queue.len()
+	}
+
+	client.stats.mu.Lock()
+	defer client.stats.mu.Unlock()
+	return Stats{
+		Commands:   maps.Clone(client.stats.commands),
+		Reconnects: client.stats.reconnects,
+		QueueDepth: depth,
+	}
+}
+
+// statsCollector holds the counters behind [Client.Stats].
+type statsCollector struct {
+	mu         sync.Mutex
+	commands   map[MessageType]CommandStats
+	reconnects uint64
+}
+
+// observe counts one request of command.
+func (s *statsCollector) observe(command MessageType, duration time.Duration, err error) {
+	s.update(command, func(stats *CommandStats) {
+		stats.Requests++
+		if err != nil {
+			stats.Errors++
+		}
+		stats.Duration += duration
+	})
+}
+
+// waited counts the time that a request of command spent in the queue.
+func (s *statsCollector) waited(command MessageType, wait time.Duration) {
+	s.update(command, func(stats *CommandStats) { stats.Wait += wait })
+}
+
+// coalesced counts a call that shares the request of another one.
+func (s *statsCollector) coalesced(command MessageType) {
+	s.update(command, func(stats *CommandStats) { stats.Coalesced++ })
+}
+
+func (s *statsCollector) update(command MessageType, fn func(*CommandStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.commands == nil {
+		s.commands = make(map[MessageType]CommandStats)
+	}
+	stats := s.commands[command]
+	fn(&stats)
+	s.commands[command] = stats
+}
+
+func (s *statsCollector) reconnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reconnects++
+}
+
+// requestCommand returns the command of a request as it is counted in
+// [Stats]: the byte after [ExecCommand], or [Auth].
+func requestCommand(msg []byte) MessageType {
+	if len(msg) >= 2 && msg[0] == ExecCommand {
+		return msg[1]
+	}
+	if len(msg) >= 1 {
+		return msg[0]
+	}
+	return 0
+}
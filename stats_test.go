@@ -0,0 +1,124 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+)
+
+func TestStats(t *testing.T) {
+	type counts struct {
+		requests uint64
+		errors   uint64
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T) *rcon.Client
+		want map[rcon.MessageType]counts
+	}{
+		{
+			name: "auth",
+			run: func(t *testing.T) *rcon.Client {
+				_, client := connect(t)
+				return client
+			},
+			want: map[rcon.MessageType]counts{rcon.Auth: {1, 0}},
+		},
+		{
+			name: "commands",
+			run: func(t *testing.T) *rcon.Client {
+				_, client := connect(t)
+				client.GetPlayerList()
+				client.GetPlayerList()
+				client.Announce("hello")
+				return client
+			},
+			want: map[rcon.MessageType]counts{
+				rcon.Auth:          {1, 0},
+				rcon.GetPlayerList: {2, 0},
+				rcon.Announce:      {1, 0},
+			},
+		},
+		{
+			name: "timeout",
+			run: func(t *testing.T) *rcon.Client {
+				server, client := connect(t)
+				server.SetDelay(100 * time.Millisecond)
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+				client.SaveContext(ctx)
+				return client
+			},
+			want: map[rcon.MessageType]counts{
+				rcon.Auth: {1, 0},
+				rcon.Save: {1, 1},
+			},
+		},
+		{
+			name: "invalid argument",
+			run: func(t *testing.T) *rcon.Client {
+				_, client := connect(t)
+				// Nothing is sent.
+				client.KickPlayer("bob", "AFK")
+				return client
+			},
+			want: map[rcon.MessageType]counts{rcon.Auth: {1, 0}},
+		},
+		{
+			name: "unparsable response",
+			run: func(t *testing.T) *rcon.Client {
+				client := replay(t, "\x02\x40", "garbage")
+				if _, err := client.GetPlayerList(); err == nil {
+					t.Error("GetPlayerList succeeded, want a parse error")
+				}
+				return client
+			},
+			want: map[rcon.MessageType]counts{
+				rcon.Auth:          {1, 0},
+				rcon.GetPlayerList: {1, 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := tt.run(t).Stats()
+
+			got := make(map[rcon.MessageType]counts)
+			for command, s := range stats.Commands {
+				got[command] = counts{s.Requests, s.Errors}
+				if s.Requests > s.Errors && s.Duration <= 0 {
+					t.Errorf("%s: duration %v, want more than zero", rcon.CommandName(command), s.Duration)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("stats for %d commands, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for command, want := range tt.want {
+				if got[command] != want {
+					t.Errorf("%s: got %+v, want %+v", rcon.CommandName(command), got[command], want)
+				}
+			}
+		})
+	}
+}
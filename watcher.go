@@ -0,0 +1,299 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the kind of change that an [Event] describes.
+type EventType int
+
+const (
+	// PlayerJoined means that a player connected to the server.
+	PlayerJoined EventType = iota
+	// PlayerLeft means that a player disconnected from the server.
+	PlayerLeft
+	// PlayerSpawned means that a player left the class selection screen
+	// and appeared in the player data.
+	PlayerSpawned
+	// PlayerDied means that a player is still connected, but not part of
+	// the player data anymore.
+	PlayerDied
+	// PlayerRespawned means that the growth of a player was reset between
+	// two polls, which happens when a player dies and spawns again as the
+	// same class.
+	PlayerRespawned
+	// ClassChanged means that a player spawned as a different class
+	// between two polls.
+	ClassChanged
+	// PollFailed means that the server could not be polled. The Err field
+	// of the event contains the reason.
+	PollFailed
+)
+
+func (t EventType) String() string {
+	switch t {
+	case PlayerJoined:
+		return "PlayerJoined"
+	case PlayerLeft:
+		return "PlayerLeft"
+	case PlayerSpawned:
+		return "PlayerSpawned"
+	case PlayerDied:
+		return "PlayerDied"
+	case PlayerRespawned:
+		return "PlayerRespawned"
+	case ClassChanged:
+		return "ClassChanged"
+	case PollFailed:
+		return "PollFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+// An Event is a change of the state of a player.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// Player is the latest known state of the player. Players that have
+	// not spawned only have an ID and a name.
+	Player Player
+	// Previous is the state of the player before the change. It is only
+	// set for PlayerDied, PlayerRespawned and ClassChanged.
+	Previous *Player
+	// Err is only set for PollFailed.
+	Err error
+}
+
+// A Snapshot is the state of all players at one point in time.
+type Snapshot struct {
+	Time time.Time
+	// ServerTime is the timestamp of the player data. The difference to
+	// Time is the clock skew of the server. It is zero if the server did
+	// not send a timestamp.
+	ServerTime time.Time
+	// Players are all connected players, as reported by GetPlayerList.
+	Players []Player
+	// Spawned are all players that have spawned, as reported by
+	// GetPlayerData.
+	Spawned []Player
+}
+
+// TakeSnapshot polls the player list and the player data.
+func (client *Client) TakeSnapshot(ctx context.Context) (*Snapshot, error) {
+	players, err := client.GetPlayerListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spawned, serverTime, err := client.GetPlayerDataWithTimestampContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{time.Now(), serverTime, players, spawned}, nil
+}
+
+// DiffSnapshots returns the events that happened between two snapshots.
+//
+// If prev is nil, every player in next is reported as joined and every
+// spawned player as spawned.
+func DiffSnapshots(prev, next *Snapshot) []Event {
+	if prev == nil {
+		prev = &Snapshot{}
+	}
+
+	prevPlayers := indexPlayers(prev.Players)
+	nextPlayers := indexPlayers(next.Players)
+	prevSpawned := indexPlayers(prev.Spawned)
+	nextSpawned := indexPlayers(next.Spawned)
+
+	// Players that are in the player data, but not (yet) in the list are
+	// still connected.
+	for id, player := range nextSpawned {
+		if _, ok := nextPlayers[id]; !ok {
+			nextPlayers[id] = player
+		}
+	}
+	for id, player := range prevSpawned {
+		if _, ok := prevPlayers[id]; !ok {
+			prevPlayers[id] = player
+		}
+	}
+
+	var events []Event
+	event := func(t EventType, player Player, previous *Player) {
+		events = append(events, Event{Type: t, Time: next.Time, Player: player, Previous: previous})
+	}
+
+	for _, player := range mergedOrder(prev, next) {
+		id := player.ID
+		before, wasConnected := prevPlayers[id]
+		_, isConnected := nextPlayers[id]
+		beforeSpawned, wasSpawned := prevSpawned[id]
+		afterSpawned, isSpawned := nextSpawned[id]
+
+		if isSpawned {
+			player = afterSpawned
+		} else if isConnected {
+			player = nextPlayers[id]
+		} else if wasSpawned {
+			player = beforeSpawned
+		} else {
+			player = before
+		}
+
+		switch {
+		case !wasConnected && isConnected:
+			event(PlayerJoined, player, nil)
+			if isSpawned {
+				event(PlayerSpawned, player, nil)
+			}
+		case wasConnected && !isConnected:
+			event(PlayerLeft, player, nil)
+		case !wasSpawned && isSpawned:
+			event(PlayerSpawned, player, nil)
+		case wasSpawned && !isSpawned:
+			event(PlayerDied, player, &beforeSpawned)
+		case wasSpawned && isSpawned && afterSpawned.DinoClass != beforeSpawned.DinoClass:
+			event(ClassChanged, player, &beforeSpawned)
+		case wasSpawned && isSpawned && afterSpawned.Growth < beforeSpawned.Growth:
+			event(PlayerRespawned, player, &beforeSpawned)
+		}
+	}
+
+	return events
+}
+
+func indexPlayers(players []Player) map[PlayerID]Player {
+	index := make(map[PlayerID]Player, len(players))
+	for _, player := range players {
+		index[player.ID] = player
+	}
+	return index
+}
+
+// mergedOrder returns every player of both snapshots once, in the order in
+// which the server reported them, so that events have a stable order.
+func mergedOrder(prev, next *Snapshot) []Player {
+	seen := make(map[PlayerID]bool)
+	var players []Player
+	for _, list := range [][]Player{next.Players, next.Spawned, prev.Players, prev.Spawned} {
+		for _, player := range list {
+			if !seen[player.ID] {
+				seen[player.ID] = true
+				players = append(players, player)
+			}
+		}
+	}
+	return players
+}
+
+// DefaultWatchInterval is the time between two polls of a [Watcher] that
+// was created without a valid interval.
+const DefaultWatchInterval = 5 * time.Second
+
+// A Watcher polls a server on an interval and reports changes of the
+// players as events.
+type Watcher struct {
+	client   Commander
+	interval time.Duration
+	events   chan Event
+	started  atomic.Bool
+}
+
+// NewWatcher returns a Watcher that polls the server using client every
+// interval. client may be a [*Client] or a [*Pool]. Call [Watcher.Run] to
+// start polling.
+//
+// An interval of zero or less means [DefaultWatchInterval].
+func NewWatcher(client Commander, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	return &Watcher{
+		client:   client,
+		interval: interval,
+		events:   make(chan Event, 64),
+	}
+}
+
+// Events returns the channel that the events are sent to. It is closed
+// when [Watcher.Run] returns.
+func (watcher *Watcher) Events() <-chan Event {
+	return watcher.events
+}
+
+// Run polls the server until ctx is done and returns the error of ctx.
+//
+// The first poll reports all players that are already connected as joined.
+// Failed polls are reported as PollFailed events and do not stop the
+// watcher. The events of the next successful poll are relative to the last
+// successful one.
+//
+// A Watcher can only run once. Later calls of Run return
+// [ErrWatcherStarted] right away.
+func (watcher *Watcher) Run(ctx context.Context) error {
+	if !watcher.started.CompareAndSwap(false, true) {
+		return ErrWatcherStarted
+	}
+	defer close(watcher.events)
+
+	var prev *Snapshot
+
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+
+	for {
+		next, err := watcher.client.TakeSnapshot(ctx)
+		if err != nil {
+			if err := contextError(ctx); err != nil {
+				return err
+			}
+			err = watcher.send(ctx, Event{Type: PollFailed, Time: time.Now(), Err: err})
+		} else {
+			for _, event := range DiffSnapshots(prev, next) {
+				err = watcher.send(ctx, event)
+				if err != nil {
+					break
+				}
+			}
+			prev = next
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (watcher *Watcher) send(ctx context.Context, event Event) error {
+	select {
+	case watcher.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
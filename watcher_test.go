@@ -0,0 +1,301 @@
+/*
+Copyright (C) 2025  Marius Becker
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package theislercon_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	rcon "github.com/butt4cak3/theislercon"
+	"github.com/butt4cak3/theislercon/rcontest"
+)
+
+var (
+	alice        = rcon.Player{ID: "76561198000000001", Name: "Alice"}
+	aliceTroodon = rcon.Player{ID: "76561198000000001", Name: "Alice", DinoClass: rcon.Troodon, Growth: 75, Health: 100}
+	aliceStego   = rcon.Player{ID: "76561198000000001", Name: "Alice", DinoClass: rcon.Stegosaurus, Growth: 10, Health: 100}
+	aliceYoung   = rcon.Player{ID: "76561198000000001", Name: "Alice", DinoClass: rcon.Troodon, Growth: 10, Health: 100}
+	bob          = rcon.Player{ID: "76561198000000002", Name: "Bob"}
+)
+
+type wantEvent struct {
+	Type     rcon.EventType
+	Player   rcon.Player
+	Previous *rcon.Player
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	tests := []struct {
+		name string
+		prev *rcon.Snapshot
+		next *rcon.Snapshot
+		want []wantEvent
+	}{
+		{
+			name: "first snapshot",
+			prev: nil,
+			next: &rcon.Snapshot{Players: []rcon.Player{alice, bob}, Spawned: []rcon.Player{aliceTroodon}},
+			want: []wantEvent{
+				{rcon.PlayerJoined, aliceTroodon, nil},
+				{rcon.PlayerSpawned, aliceTroodon, nil},
+				{rcon.PlayerJoined, bob, nil},
+			},
+		},
+		{
+			name: "no change",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			next: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			want: nil,
+		},
+		{
+			name: "spawned",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}},
+			next: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			want: []wantEvent{{rcon.PlayerSpawned, aliceTroodon, nil}},
+		},
+		{
+			name: "died",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			next: &rcon.Snapshot{Players: []rcon.Player{alice}},
+			want: []wantEvent{{rcon.PlayerDied, alice, &aliceTroodon}},
+		},
+		{
+			name: "class changed",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			next: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceStego}},
+			want: []wantEvent{{rcon.ClassChanged, aliceStego, &aliceTroodon}},
+		},
+		{
+			name: "respawned",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			next: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceYoung}},
+			want: []wantEvent{{rcon.PlayerRespawned, aliceYoung, &aliceTroodon}},
+		},
+		{
+			name: "left before spawning",
+			prev: &rcon.Snapshot{Players: []rcon.Player{bob}},
+			next: &rcon.Snapshot{},
+			want: []wantEvent{{rcon.PlayerLeft, bob, nil}},
+		},
+		{
+			// The player list only has the ID and the name. The event must
+			// carry the last state from the player data.
+			name: "left while spawned",
+			prev: &rcon.Snapshot{Players: []rcon.Player{alice}, Spawned: []rcon.Player{aliceTroodon}},
+			next: &rcon.Snapshot{},
+			want: []wantEvent{{rcon.PlayerLeft, aliceTroodon, nil}},
+		},
+		{
+			name: "spawned but not yet listed",
+			prev: &rcon.Snapshot{},
+			next: &rcon.Snapshot{Spawned: []rcon.Player{aliceTroodon}},
+			want: []wantEvent{
+				{rcon.PlayerJoined, aliceTroodon, nil},
+				{rcon.PlayerSpawned, aliceTroodon, nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []wantEvent
+			for _, event := range rcon.DiffSnapshots(tt.prev, tt.next) {
+				got = append(got, wantEvent{event.Type, event.Player, event.Previous})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcherInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, client := connect(t)
+		watcher := rcon.NewWatcher(client, interval)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if err := watcher.Run(ctx); err != context.DeadlineExceeded {
+			t.Errorf("interval %v: Run returned %v, want %v", interval, err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestWatcherRunTwice(t *testing.T) {
+	_, client := connect(t)
+	watcher := rcon.NewWatcher(client, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- watcher.Run(ctx) }()
+	}
+
+	// One of the calls returns an error right away, the other one runs
+	// until ctx is done.
+	var canceled, failed int
+	for range 2 {
+		switch err := <-errs; {
+		case errors.Is(err, context.DeadlineExceeded):
+			canceled++
+		case errors.Is(err, rcon.ErrWatcherStarted):
+			failed++
+		default:
+			t.Errorf("Run returned %v", err)
+		}
+	}
+	if canceled != 1 || failed != 1 {
+		t.Errorf("%d calls ran and %d failed, want 1 each", canceled, failed)
+	}
+	if _, ok := <-watcher.Events(); ok {
+		t.Error("Events is not closed after Run returned")
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	tests := []struct {
+		name string
+		// players are connected before the watcher starts.
+		players []rcon.Player
+		// change is applied to the world after the first poll.
+		change func(world *rcontest.World)
+		want   []rcon.EventType
+	}{
+		{
+			name:   "joined",
+			change: func(world *rcontest.World) { world.AddPlayer(bob) },
+			want:   []rcon.EventType{rcon.PlayerJoined},
+		},
+		{
+			name:   "joined and spawned",
+			change: func(world *rcontest.World) { world.AddPlayer(aliceTroodon) },
+			want:   []rcon.EventType{rcon.PlayerJoined, rcon.PlayerSpawned},
+		},
+		{
+			name:    "spawned",
+			players: []rcon.Player{alice},
+			change:  func(world *rcontest.World) { world.Players[0] = aliceYoung },
+			want:    []rcon.EventType{rcon.PlayerSpawned},
+		},
+		{
+			name:    "grew",
+			players: []rcon.Player{aliceYoung},
+			change:  func(world *rcontest.World) { world.Players[0] = aliceTroodon },
+			want:    nil,
+		},
+		{
+			name:    "class changed",
+			players: []rcon.Player{aliceYoung},
+			change:  func(world *rcontest.World) { world.Players[0] = aliceStego },
+			want:    []rcon.EventType{rcon.ClassChanged},
+		},
+		{
+			name:    "died",
+			players: []rcon.Player{aliceYoung},
+			change:  func(world *rcontest.World) { world.Players[0] = alice },
+			want:    []rcon.EventType{rcon.PlayerDied},
+		},
+		{
+			name:    "left",
+			players: []rcon.Player{aliceYoung, bob},
+			change:  func(world *rcontest.World) { world.RemovePlayer(alice.ID) },
+			want:    []rcon.EventType{rcon.PlayerLeft},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := connect(t)
+			server.Update(func(world *rcontest.World) {
+				world.Players = append(world.Players, tt.players...)
+			})
+
+			watcher := rcon.NewWatcher(client, 50*time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go watcher.Run(ctx)
+
+			// The first poll reports the players that are already there.
+			var initial []rcon.EventType
+			for _, player := range tt.players {
+				initial = append(initial, rcon.PlayerJoined)
+				if player.DinoClass != "" {
+					initial = append(initial, rcon.PlayerSpawned)
+				}
+			}
+			expectEvents(t, watcher, initial)
+
+			server.Update(tt.change)
+			expectEvents(t, watcher, tt.want)
+		})
+	}
+}
+
+// expectEvents reads the events of the next poll from watcher.
+func expectEvents(t *testing.T, watcher *rcon.Watcher, want []rcon.EventType) {
+	t.Helper()
+
+	var got []rcon.EventType
+	timeout := time.After(120 * time.Millisecond)
+	for len(got) < len(want) {
+		select {
+		case event := <-watcher.Events():
+			if event.Type == rcon.PollFailed {
+				t.Fatalf("poll failed: %v", event.Err)
+			}
+			got = append(got, event.Type)
+		case <-timeout:
+			t.Fatalf("got events %v, want %v", got, want)
+		}
+	}
+	// Let the next poll finish without any more events.
+	select {
+	case event := <-watcher.Events():
+		got = append(got, event.Type)
+	case <-time.After(80 * time.Millisecond):
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+}
+
+func TestWatcherPollFailed(t *testing.T) {
+	server, client := connect(t)
+	watcher := rcon.NewWatcher(client, 20*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server.Close()
+	go watcher.Run(ctx)
+
+	for range 2 {
+		select {
+		case event := <-watcher.Events():
+			if event.Type != rcon.PollFailed || event.Err == nil {
+				t.Fatalf("got event %+v, want a failed poll", event)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no event for a failed poll")
+		}
+	}
+}